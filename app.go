@@ -4,90 +4,509 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
 // Cli framework main struct
 type App struct {
+	// Name of the program used in the usage lines
+	Name string
+
 	Commands map[string]*Command
 	Writer   io.Writer
 	Reader   io.Reader
 
+	// Options shared by every registered command
+	Flags FlagList
+
+	// Built-in options, i.e -h, -q, -v and --ansi, that give way to the global and
+	// command options using the same names
+	builtins FlagList
+
+	// Maximum number of edits between an unknown command or option and the
+	// names suggested with "Did you mean?"
+	SuggestionThreshold int
+	DisableSuggestions  bool
+
+	// Don't resolve unique prefixes of long options and commands, i.e `--verb` for
+	// `--verbose` or `mig` for `migrate`
+	DisableAbbreviations bool
+
+	// Styles of the output tags, i.e `<info>` or custom ones like `<success>`
+	Styles map[string]Style
+
+	// Replace the `@path` args with the args read from the files, i.e `app build @args.txt`
+	ResponseFiles bool
+
 	DefaultCmd *Command
 }
 
-// Creates a new App struct and adds the null command to it
+// Creates a new App struct and adds the null and the help commands to it
 func New() *App {
 	app := &App{
+		Name:     filepath.Base(os.Args[0]),
 		Commands: make(map[string]*Command, 0),
 		Writer:   os.Stdout,
 		Reader:   os.Stdin,
+		Styles:   defaultStyles(),
+
+		SuggestionThreshold: 2,
 	}
-	app.AddCommand(homeCommand)
+	app.builtins, _ = ParseSignature("{-h|help : Display help for the given command} " +
+		"{-q|quiet : Do not output any message} " +
+		"{-v|verbose+ : Increase the verbosity of messages: -v for verbose, -vv for very verbose and -vvv for debug} " +
+		"{--ansi! : Force the colors of the output, or disable them with --no-ansi}")
+	app.MustAddCommand(homeCommand)
+	app.MustAddCommand(helpCommand)
+	app.MustAddCommand(completionCommand)
 	return app
 }
 
-// Register a new command into the system
-func (app *App) AddCommand(cmdFunc func(*App) *Command) *App {
+// Register a new command into the system. Names like `db:migrate` will be
+// registered as the `migrate` child of the `db` namespace.
+// Commands with invalid signatures or with options that conflict with
+// the global ones are not registered and the error is returned
+func (app *App) AddCommand(cmdFunc func(*App) *Command) error {
 	c := cmdFunc(app)
-	app.Commands[strings.ToLower(c.Name)] = c
+	if err := checkCommand(app.Flags, c); err != nil {
+		return err
+	}
+	insertCommand(app.Commands, nil, c)
+	return nil
+}
+
+// Same as AddCommand, but it panics if the command can't be registered
+func (app *App) MustAddCommand(cmdFunc func(*App) *Command) *App {
+	if err := app.AddCommand(cmdFunc); err != nil {
+		panic(err.Error())
+	}
 	return app
 }
 
+// Register options that apply to every command, i.e {--v|verbose} {--env=production}
+func (app *App) AddGlobalOptions(signature string) error {
+	global, err := ParseSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range global {
+		if flag.isArgument() {
+			return fmt.Errorf("Global signatures can only contain options, but `%s` is an argument!", flag.name)
+		}
+		for _, name := range flag.names() {
+			if app.Flags.option(name) != nil {
+				return fmt.Errorf("The `--%s` global option is already registered!", name)
+			}
+		}
+	}
+
+	for _, cmd := range app.Commands {
+		if err := checkCommand(global, cmd); err != nil {
+			return err
+		}
+	}
+
+	app.Flags = append(app.Flags, global...)
+	return nil
+}
+
+// Same as AddGlobalOptions, but it panics if the options can't be registered
+func (app *App) MustAddGlobalOptions(signature string) *App {
+	if err := app.AddGlobalOptions(signature); err != nil {
+		panic(err.Error())
+	}
+	return app
+}
+
+// Check that the signatures of the command and of its children are valid and
+// that their options don't use any name or alias of the global options
+func checkCommand(global FlagList, cmd *Command) error {
+	if err := cmd.parse(); err != nil {
+		return fmt.Errorf("The signature of the `%s` command is invalid: %w", cmd.Name, err)
+	}
+
+	for _, flag := range cmd.Flags {
+		if flag.isArgument() {
+			continue
+		}
+		for _, name := range flag.names() {
+			if global.option(name) != nil {
+				return fmt.Errorf("The `--%s` option of the `%s` command conflicts with a global option!", name, cmd.Name)
+			}
+		}
+	}
+
+	for _, child := range cmd.Commands {
+		if err := checkCommand(global, child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Start the cli framework, based on the os arguments. Those arguments should
-// follow the pattern: arg1 file, arg2 argument/option and so on
-func (app *App) Run(args []string) {
-	var cmd string
+// follow the pattern: arg1 file, arg2 argument/option and so on.
+// The returned error is also written to the app writer
+func (app *App) Run(args []string) error {
+	err := app.RunE(args)
+	if err != nil {
+		fmt.Fprintln(app.Writer, err.Error())
+	}
+	return err
+}
 
+// Same as Run, but the error is only returned. It can be a *CommandNotFoundError,
+// a *UsageError or an *ActionError and its exit code can be found with ExitCode
+func (app *App) RunE(args []string) error {
 	args = args[1:]
-	cmd, pos := findFirstArgument(args)
 
-	if pos != -1 {
-		args = append(args[:pos], args[pos+1:]...)
+	if len(args) > 0 && args[0] == completeProtocol {
+		for _, candidate := range app.complete(args[1:]) {
+			fmt.Fprintln(app.Writer, candidate)
+		}
+		return nil
+	}
+
+	if app.ResponseFiles {
+		expanded, err := expandResponseFiles(args)
+		if err != nil {
+			return &UsageError{Err: err}
+		}
+		args = expanded
 	}
 
-	if cmd, ok := app.Commands[cmd]; ok {
-		cmd.parse()
-		matcher := newMatcher(args, cmd.Flags)
+	name, pos := app.findFirstArgument(args)
 
-		if err := matcher.match(); err != nil {
-			fmt.Fprintln(app.Writer, err.Error())
-			return
+	cmd, rest, err := app.findCommand(args, name, pos)
+	if err != nil {
+		return err
+	}
+
+	// Positions in the os args for the errors, skipping the command names
+	positions := make([]int, len(rest))
+	for i := range rest {
+		positions[i] = i + 1
+		if pos != -1 && i >= pos {
+			positions[i] += len(args) - len(rest)
 		}
+	}
+	args = rest
+
+	flags := app.commandFlags(cmd)
+
+	matcher := newMatcher(args, flags)
+	matcher.suggestionThreshold = app.suggestionThreshold()
+	matcher.positions = positions
+	matcher.abbreviations = !app.DisableAbbreviations
+	if app.usesBuiltin(cmd, "help") {
+		matcher.helpOption = "help"
+	}
+
+	if err := matcher.match(); err != nil {
+		return &UsageError{Command: cmd.FullName(), Err: err}
+	}
+
+	// The help option can be abbreviated, i.e `--hel`
+	if _, ok := matcher.options[matcher.helpOption]; ok {
+		app.renderHelp(cmd)
+		return nil
+	}
 
-		ctx := newContext(app.Reader, app.Writer, matcher.arguments, matcher.options)
+	ctx := newContext(app.Reader, app.Writer, matcher.arguments, matcher.options)
+	ctx.argumentSources = matcher.argumentSources
+	ctx.optionSources = matcher.optionSources
+	ctx.passthrough = matcher.passthrough
+	ctx.negatable = map[string]bool{}
+	for _, flag := range flags {
+		if flag.isNegatable() {
+			ctx.negatable[flag.name] = true
+		}
+	}
+	ctx.styles = app.Styles
+	ansi := TristateUnset
+	if app.usesBuiltin(cmd, "ansi") {
+		ansi = ctx.OptionState("ansi")
+	}
+	ctx.decorated = colorsEnabled(app.Writer, ansi)
+	switch {
+	case cmd.Action != nil:
 		ctx.AppendHandler(cmd.Action)
-		ctx.Run()
+	case cmd.ActionE != nil:
+		ctx.AppendHandler(cmd.ActionE.handler())
+	default:
+		ctx.AppendHandler(func(ctx *Context) {
+			app.renderNamespace(cmd)
+		})
+	}
+	ctx.Run()
+
+	if err := ctx.Err(); err != nil {
+		return &ActionError{Command: cmd.FullName(), Err: err}
+	}
+	return nil
+}
+
+// Get the flags of the command: the global options, the built-in options
+// that are still free and the command's own flags
+func (app *App) commandFlags(cmd *Command) FlagList {
+	flags := append(FlagList{}, app.Flags...)
+	taken := append(append(FlagList{}, app.Flags...), cmd.Flags...)
 
-		return
+	for _, builtin := range app.builtins {
+		if option := builtin.withoutNames(taken); option != nil {
+			flags = append(flags, option)
+		}
 	}
 
-	fmt.Fprintf(app.Writer, "Command `%s` was not found!", cmd)
+	return append(flags, cmd.Flags...)
+}
+
+// Check if the built-in option with the name is used by the command, and not a global
+// option or an option of the command with the same name, i.e {--help : Show the manual}
+func (app *App) usesBuiltin(cmd *Command, name string) bool {
+	return app.builtins.option(name) != nil && app.Flags.option(name) == nil && cmd.Flags.option(name) == nil
 }
 
-// Find the first argument from the os args
-func findFirstArgument(args []string) (string, int) {
-	for i, arg := range args {
+// Find the command by its name and walk down the command tree as long as the
+// following args are names of child commands, i.e: `db migrate`.
+// Returns the command and the args that were not used for the lookup
+func (app *App) findCommand(args []string, name string, pos int) (*Command, []string, error) {
+	if pos == -1 {
+		return app.Commands[""], args, nil
+	}
+
+	cmd, err := app.resolveCommand(app.Commands, name, true)
+	if err != nil {
+		return nil, args, err
+	}
+	if cmd == nil {
+		return nil, args, app.commandNotFound(name)
+	}
+
+	rest := append([]string{}, args[:pos]...)
+	for pos++; pos < len(args); pos++ {
+		if strings.HasPrefix(args[pos], "-") {
+			break
+		}
+		// Only the children of namespaces are abbreviated, otherwise the
+		// arguments of the commands could be taken for command names
+		child, err := app.resolveCommand(cmd.Commands, args[pos], cmd.isNamespace())
+		if err != nil {
+			return nil, args, err
+		}
+		if child == nil {
+			// Namespaces don't accept arguments so this must be a wrong command name
+			if cmd.isNamespace() {
+				return nil, args, app.commandNotFound(cmd.FullName() + ":" + args[pos])
+			}
+			break
+		}
+		cmd = child
+	}
+
+	return cmd, append(rest, args[pos:]...), nil
+}
+
+// Find a command by its name relative to the given commands. When abbreviations are
+// allowed, every segment can be a unique prefix, i.e `mig` for `migrate` or `d:m` for `db:migrate`
+func (app *App) resolveCommand(commands map[string]*Command, name string, abbreviate bool) (*Command, error) {
+	if cmd := lookupCommand(commands, name); cmd != nil || !abbreviate || app.DisableAbbreviations {
+		return cmd, nil
+	}
+
+	var cmd *Command
+	for _, segment := range strings.Split(strings.ToLower(name), ":") {
+		if cmd = commands[segment]; cmd != nil {
+			commands = cmd.Commands
+			continue
+		}
+		if segment == "" {
+			return nil, nil
+		}
+
+		candidates := []string{}
+		for key, child := range commands {
+			if key != "" && !child.Hidden && strings.HasPrefix(key, segment) {
+				candidates = append(candidates, child.FullName())
+				cmd = child
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+			return nil, nil
+		case 1:
+			commands = cmd.Commands
+		default:
+			sort.Strings(candidates)
+			if cmd.parent != nil {
+				segment = cmd.parent.FullName() + ":" + segment
+			}
+			return nil, &AmbiguousCommandError{Name: segment, Candidates: candidates}
+		}
+	}
+
+	return cmd, nil
+}
+
+// Build the error for an unknown command with the names that are close to it
+func (app *App) commandNotFound(name string) error {
+	return &CommandNotFoundError{
+		Name:        name,
+		Suggestions: suggest(name, commandNames(app.Commands), app.suggestionThreshold()),
+	}
+}
+
+// Get the threshold for the suggestions, 0 if they are disabled
+func (app *App) suggestionThreshold() int {
+	if app.DisableSuggestions {
+		return 0
+	}
+	return app.SuggestionThreshold
+}
+
+// Get the full names of the visible commands and namespaces
+func commandNames(commands map[string]*Command) []string {
+	names := []string{}
+
+	for _, cmd := range commands {
+		if cmd.Name == "" || cmd.Hidden {
+			continue
+		}
+		names = append(names, cmd.FullName())
+		names = append(names, commandNames(cmd.Commands)...)
+	}
+
+	return names
+}
+
+// Find a command by its name relative to the given commands, i.e `db:migrate`
+func lookupCommand(commands map[string]*Command, name string) *Command {
+	var cmd *Command
+
+	for _, segment := range strings.Split(name, ":") {
+		if cmd = commands[strings.ToLower(segment)]; cmd == nil {
+			return nil
+		}
+		commands = cmd.Commands
+	}
+
+	return cmd
+}
+
+// Find the first argument from the os args. The values of the global options are skipped,
+// i.e `staging` in `--env staging deploy`, and args after `--` are never command names
+func (app *App) findFirstArgument(args []string) (string, int) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
 		if len(arg) > 0 && arg[0] != '-' {
 			return arg, i
 		}
+		if app.takesNextArg(arg) {
+			i++
+		}
 	}
 	return "", -1
 }
 
+// Check if the arg is a global option that takes the next arg as its value,
+// i.e `--env` or `-e` but not `--env=local` or `-elocal`
+func (app *App) takesNextArg(arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+	if !strings.HasPrefix(arg, "--") {
+		return app.Flags.clusterValueOption(strings.TrimPrefix(arg, "-")) != nil
+	}
+
+	name := arg[2:]
+	if app.Flags.option(name) == nil && !app.DisableAbbreviations && name != "" {
+		if names := app.Flags.optionPrefix(name); len(names) == 1 {
+			name = names[0]
+		}
+	}
+	option := app.Flags.option(name)
+	return option != nil && option.acceptValue()
+}
+
+// Collect the runnable commands from the tree
+func collectCommands(commands map[string]*Command) []*Command {
+	list := []*Command{}
+
+	for _, cmd := range commands {
+		if cmd.Name == "" || cmd.Hidden {
+			continue
+		}
+		if cmd.Action != nil || cmd.ActionE != nil {
+			list = append(list, cmd)
+		}
+		list = append(list, collectCommands(cmd.Commands)...)
+	}
+
+	return list
+}
+
+// Write the commands grouped by their namespace, like Laravel's console does.
+// Commands without a namespace are listed first
+func (app *App) listCommands(commands map[string]*Command) {
+	groups := map[string][]*Command{}
+	namespaces := []string{}
+
+	list := collectCommands(commands)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].FullName() < list[j].FullName()
+	})
+
+	for _, cmd := range list {
+		namespace := ""
+		if name := cmd.FullName(); strings.Contains(name, ":") || len(cmd.Commands) > 0 {
+			namespace = strings.SplitN(name, ":", 2)[0]
+		}
+		if _, ok := groups[namespace]; !ok && namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+		groups[namespace] = append(groups[namespace], cmd)
+	}
+
+	for _, cmd := range groups[""] {
+		fmt.Fprintf(app.Writer, "\t%s - %s\n", cmd.FullName(), cmd.Description)
+	}
+
+	for _, namespace := range namespaces {
+		fmt.Fprintf(app.Writer, " %s\n", namespace)
+		for _, cmd := range groups[namespace] {
+			fmt.Fprintf(app.Writer, "\t%s - %s\n", cmd.FullName(), cmd.Description)
+		}
+	}
+}
+
+// Write the commands of a namespace without an action of its own
+func (app *App) renderNamespace(namespace *Command) {
+	fmt.Fprintf(app.Writer, "The commands of the `%s` namespace are:\n", namespace.FullName())
+	app.listCommands(namespace.Commands)
+}
+
+// Write the app usage with all the commands
+func (app *App) renderHome() {
+	fmt.Fprintln(app.Writer, "Usage:")
+	fmt.Fprintf(app.Writer, "\t%s command [arguments]\n", app.Name)
+	fmt.Fprintln(app.Writer, "The commands are:")
+	app.listCommands(app.Commands)
+}
+
 // Command for default app usage
 func homeCommand(app *App) *Command {
 	return &Command{
 		Name: "",
 		Action: func(ctx *Context) {
-			fmt.Fprintln(app.Writer, "Usage:")
-			fmt.Fprintln(app.Writer, "\tapp command [arguments]")
-			fmt.Fprintln(app.Writer, "The commands are:")
-			for _, cmd := range app.Commands {
-				if cmd.Name != "" {
-					fmt.Fprintf(app.Writer, "\t%s - %s\n", cmd.Name, cmd.Description)
-				}
-			}
+			app.renderHome()
 		},
 	}
 }
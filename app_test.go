@@ -0,0 +1,545 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Creates an app that writes into a buffer
+func testApp(commands ...*Command) (*App, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	app := New()
+	app.Name = "app"
+	app.Writer = out
+
+	for _, c := range commands {
+		cmd := c
+		app.MustAddCommand(func(*App) *Command { return cmd })
+	}
+
+	return app, out
+}
+
+// Creates a command that writes its full name and arguments when it runs
+func echoCommand(name string, signature string) *Command {
+	cmd := &Command{
+		Name:        name,
+		Signature:   signature,
+		Description: "Runs " + name,
+	}
+	cmd.Action = func(ctx *Context) {
+		ctx.Writer.Write([]byte(cmd.FullName()))
+		for _, arg := range cmd.Flags {
+			if res, err := ctx.Argument(arg.name); err == nil {
+				ctx.Writer.Write([]byte(" " + strings.Join(res.StrSlice(), ",")))
+			}
+		}
+	}
+	return cmd
+}
+
+func TestNamespacedCommands(t *testing.T) {
+	app, out := testApp(
+		echoCommand("db:migrate", "{dir=.}"),
+		echoCommand("db:seed", ""),
+		echoCommand("make:migration:create", "{name}"),
+	)
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args("app", "db:migrate"), "db:migrate ."},
+		{args("app", "DB:Migrate", "src"), "db:migrate src"},
+		{args("app", "db", "migrate", "src"), "db:migrate src"},
+		{args("app", "db", "seed"), "db:seed"},
+		{args("app", "make", "migration:create", "users"), "make:migration:create users"},
+		{args("app", "make:migration", "create", "users"), "make:migration:create users"},
+		{args("app", "db", "unknown"), "Command `db:unknown` was not found!\n"},
+	}
+
+	for i, test := range tests {
+		out.Reset()
+		app.Run(test.args)
+
+		if out.String() != test.expected {
+			t.Errorf("Test #%d expected output `%s` but got `%s`!", i+1, test.expected, out.String())
+		}
+	}
+}
+
+func TestNestedCommandTree(t *testing.T) {
+	db := echoCommand("db", "")
+	db.AddCommand(echoCommand("migrate", "{dir=.}"), echoCommand("migrate:fresh", ""))
+	app, out := testApp(db, echoCommand("db:seed", ""))
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args("app", "db"), "db"},
+		{args("app", "db", "migrate"), "db:migrate ."},
+		{args("app", "db:migrate:fresh"), "db:migrate:fresh"},
+		{args("app", "db", "migrate", "fresh"), "db:migrate:fresh"},
+		{args("app", "db", "seed"), "db:seed"},
+	}
+
+	for i, test := range tests {
+		out.Reset()
+		app.Run(test.args)
+
+		if out.String() != test.expected {
+			t.Errorf("Test #%d expected output `%s` but got `%s`!", i+1, test.expected, out.String())
+		}
+	}
+}
+
+func TestNamespaceListing(t *testing.T) {
+	app, out := testApp(
+		echoCommand("db:seed", ""),
+		echoCommand("build", ""),
+		echoCommand("db:migrate", ""),
+	)
+
+	app.Run(args("app"))
+	expected := "Usage:\n\tapp command [arguments]\nThe commands are:\n" +
+		"\tbuild - Runs build\n" +
+		"\tcompletion - Generate the shell completion script\n" +
+		"\thelp - Display help for a command\n" +
+		" db\n\tdb:migrate - Runs db:migrate\n\tdb:seed - Runs db:seed\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected home listing `%s` but got `%s`!", expected, out.String())
+	}
+
+	out.Reset()
+	app.Run(args("app", "db"))
+	expected = "The commands of the `db` namespace are:\n" +
+		" db\n\tdb:migrate - Runs db:migrate\n\tdb:seed - Runs db:seed\n"
+
+	if out.String() != expected {
+		t.Errorf("Expected namespace listing `%s` but got `%s`!", expected, out.String())
+	}
+}
+
+func TestGlobalOptions(t *testing.T) {
+	var options map[string]*Result
+	build := &Command{
+		Name:      "build",
+		Signature: "{file} {--output=}",
+		Action: func(ctx *Context) {
+			options = ctx.Options
+		},
+	}
+
+	app, out := testApp()
+	app.MustAddGlobalOptions("{--v|verbose} {--env=production} {--config=}")
+	app.MustAddCommand(func(*App) *Command { return build })
+
+	app.Run(args("app", "build", "main.go", "-v", "--config", "app.yml", "--output=out"))
+
+	expected := map[string]*Result{
+		"verbose": &Result{},
+		"env":     &Result{"production"},
+		"config":  &Result{"app.yml"},
+		"output":  &Result{"out"},
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Expected options %s but got %s! Output: %s", expected, options, out.String())
+	}
+
+	// The values of the global options before the command are not command names
+	for i, test := range [][]string{
+		args("app", "--env", "staging", "build", "main.go"),
+		args("app", "--config", "app.yml", "--env", "staging", "build", "main.go"),
+		args("app", "--en", "staging", "build", "main.go"),
+		args("app", "--env=staging", "build", "main.go"),
+	} {
+		options = nil
+		if err := app.RunE(test); err != nil {
+			t.Errorf("Test #%d failed with error: %s", i+1, err)
+		}
+		if env := options["env"]; !reflect.DeepEqual(env, &Result{"staging"}) {
+			t.Errorf("Test #%d expected env `staging` but got %v!", i+1, env)
+		}
+	}
+}
+
+func TestGlobalOptionsConflicts(t *testing.T) {
+	app, _ := testApp()
+	app.MustAddGlobalOptions("{--v|verbose}")
+	if err := app.AddCommand(func(*App) *Command { return echoCommand("build", "{-v}") }); err == nil {
+		t.Errorf("Expected a conflict for a command registered after the global option!")
+	}
+	if lookupCommand(app.Commands, "build") != nil {
+		t.Errorf("Commands with conflicts should not be registered!")
+	}
+
+	app, _ = testApp(echoCommand("db:migrate", "{--env=}"))
+	if err := app.AddGlobalOptions("{--env=production}"); err == nil {
+		t.Errorf("Expected a conflict for a global option registered after the command!")
+	}
+
+	app, _ = testApp()
+	app.MustAddGlobalOptions("{--env=production}")
+	if err := app.AddGlobalOptions("{-e|environment=local}"); err != nil {
+		t.Errorf("Unexpected error for a new global option: %s", err)
+	}
+	if err := app.AddGlobalOptions("{--e=local}"); err == nil {
+		t.Errorf("Expected a conflict for a duplicated global option!")
+	}
+
+	if err := app.AddGlobalOptions("{file}"); err == nil {
+		t.Errorf("Expected an error for a global argument!")
+	}
+}
+
+func TestAddCommandWithInvalidSignature(t *testing.T) {
+	app, _ := testApp()
+
+	err := app.AddCommand(func(*App) *Command { return echoCommand("build", "{file} {--output=") })
+	var signatureErr *SignatureError
+	if !errors.As(err, &signatureErr) || signatureErr.Pos != 7 {
+		t.Errorf("Expected a signature error at position 7 but got `%v`!", err)
+	}
+	if lookupCommand(app.Commands, "build") != nil {
+		t.Errorf("Commands with invalid signatures should not be registered!")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustAddCommand should panic for invalid signatures!")
+		}
+	}()
+	app.MustAddCommand(func(*App) *Command { return echoCommand("build", "{}") })
+}
+
+func TestRunErrors(t *testing.T) {
+	deployErr := errors.New("Deploy failed!")
+	app, _ := testApp(
+		echoCommand("build", "{file}"),
+		&Command{
+			Name: "deploy",
+			ActionE: func(ctx *Context) error {
+				return deployErr
+			},
+		},
+		&Command{
+			Name: "release",
+			ActionE: func(ctx *Context) error {
+				return Exit("Release failed!", 3)
+			},
+		},
+	)
+
+	err := app.Run(args("app", "buidl"))
+	if _, ok := err.(*CommandNotFoundError); !ok || ExitCode(err) != 127 {
+		t.Errorf("Expected a command not found error with code 127 but got `%v`!", err)
+	}
+
+	err = app.Run(args("app", "build"))
+	if _, ok := err.(*UsageError); !ok || ExitCode(err) != 2 {
+		t.Errorf("Expected an usage error with code 2 but got `%v`!", err)
+	}
+
+	err = app.Run(args("app", "deploy"))
+	if _, ok := err.(*ActionError); !ok || !errors.Is(err, deployErr) || ExitCode(err) != 1 {
+		t.Errorf("Expected an action error with code 1 but got `%v`!", err)
+	}
+
+	err = app.Run(args("app", "release"))
+	if _, ok := err.(*ActionError); !ok || err.Error() != "Release failed!" || ExitCode(err) != 3 {
+		t.Errorf("Expected an action error with code 3 but got `%v`!", err)
+	}
+
+	err = app.Run(args("app", "build", "main.go"))
+	if err != nil || ExitCode(err) != 0 {
+		t.Errorf("Expected no error but got `%v`!", err)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	var passthrough []string
+	app, _ := testApp(&Command{
+		Name:      "exec",
+		Signature: "{--env=}",
+		Action: func(ctx *Context) {
+			passthrough = ctx.Passthrough()
+		},
+	})
+
+	if err := app.Run(args("app", "exec", "--env=local", "--", "docker", "run", "-it", "--help", "alpine")); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(passthrough, args("docker", "run", "-it", "--help", "alpine")) {
+		t.Errorf("Expected passthrough args [docker run -it --help alpine] but got %v!", passthrough)
+	}
+
+	passthrough = nil
+	app.Run(args("app", "--", "exec"))
+	if passthrough != nil {
+		t.Errorf("Args after -- should not be used as command names!")
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	app, _ := testApp(
+		echoCommand("migrate", "{--v|verbose} {--force}"),
+		echoCommand("db:seed", ""),
+		echoCommand("db:sync", ""),
+	)
+	// Prefixes like `migrat` would be resolved as abbreviations
+	app.DisableAbbreviations = true
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{args("app", "migrat"), "Command `migrat` was not found! Did you mean `migrate`?"},
+		{args("app", "db:sed"), "Command `db:sed` was not found! Did you mean `db:seed`?"},
+		{args("app", "db", "syed"), "Command `db:syed` was not found! Did you mean one of these: `db:seed`, `db:sync`?"},
+		{args("app", "deploy"), "Command `deploy` was not found!"},
+		{args("app", "migrate", "--verbos"), "The `--verbos` option does not exist. Did you mean `--verbose`?"},
+		{args("app", "migrate", "--forse"), "The `--forse` option does not exist. Did you mean `--force`?"},
+		{args("app", "migrate", "--help-me"), "The `--help-me` option does not exist."},
+		{args("app", "migrate", "--env"), "The `--env` option does not exist."},
+	}
+
+	for i, test := range tests {
+		if err := app.RunE(test.args); err == nil || err.Error() != test.err {
+			t.Errorf("Test #%d expected error `%s` but got `%v`!", i+1, test.err, err)
+		}
+	}
+
+	app.DisableSuggestions = true
+	if err := app.RunE(args("app", "migrat")); err == nil || err.Error() != "Command `migrat` was not found!" {
+		t.Errorf("Expected no suggestions when they are disabled but got `%v`!", err)
+	}
+}
+
+func TestUsageErrorPositions(t *testing.T) {
+	app, _ := testApp(echoCommand("db:migrate", "{--step:int=}"))
+
+	err := app.RunE(args("app", "--step=x", "db", "migrate"))
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || invalid.Position != 1 {
+		t.Errorf("Expected an invalid value error at position 1 but got %#v!", err)
+	}
+
+	err = app.RunE(args("app", "db", "migrate", "--step", "x"))
+	if !errors.As(err, &invalid) || invalid.Position != 4 {
+		t.Errorf("Expected an invalid value error at position 4 but got %#v!", err)
+	}
+
+	if _, ok := err.(*UsageError); !ok {
+		t.Errorf("Matcher errors should be wrapped into an usage error but got %#v!", err)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	var verbosity Verbosity
+	app, _ := testApp(&Command{
+		Name: "build",
+		Action: func(ctx *Context) {
+			verbosity = ctx.Verbosity()
+		},
+	})
+
+	tests := []struct {
+		args     []string
+		expected Verbosity
+	}{
+		{args("app", "build"), VerbosityNormal},
+		{args("app", "build", "-q"), VerbosityQuiet},
+		{args("app", "build", "--quiet", "-vv"), VerbosityQuiet},
+		{args("app", "build", "-v"), VerbosityVerbose},
+		{args("app", "build", "-vv"), VerbosityVeryVerbose},
+		{args("app", "build", "-v", "--verbose", "-v"), VerbosityDebug},
+		{args("app", "build", "-vvvvv"), VerbosityDebug},
+	}
+
+	for i, test := range tests {
+		if err := app.Run(test.args); err != nil {
+			t.Errorf("Test #%d failed with error: %s", i+1, err)
+		}
+		if verbosity != test.expected {
+			t.Errorf("Test #%d expected verbosity `%d` but got `%d`!", i+1, test.expected, verbosity)
+		}
+	}
+
+	ctx := newContext(nil, nil, map[string]*Result{}, map[string]*Result{"verbose": &Result{"1", "1"}})
+	if ctx.IsQuiet() || !ctx.IsVerbose() || !ctx.IsVeryVerbose() || ctx.IsDebug() {
+		t.Errorf("Expected a very verbose context but got verbosity `%d`!", ctx.Verbosity())
+	}
+}
+
+func TestBuiltinOptionsGiveWay(t *testing.T) {
+	var options map[string]*Result
+	app, out := testApp(&Command{
+		Name:      "work",
+		Signature: "{-q|queue=}",
+		Action: func(ctx *Context) {
+			options = ctx.Options
+		},
+	})
+
+	if err := app.Run(args("app", "work", "-q", "redis", "--quiet")); err != nil {
+		t.Errorf("Run failed with error: %s", err)
+	}
+	if expected := map[string]*Result{"queue": &Result{"redis"}, "quiet": &Result{}}; !reflect.DeepEqual(options, expected) {
+		t.Errorf("Expected options %v but got %v!", expected, options)
+	}
+
+	out.Reset()
+	app.Run(args("app", "work", "-h"))
+	if !strings.Contains(out.String(), "    --quiet ") || strings.Contains(out.String(), "-q, --quiet") {
+		t.Errorf("Expected the built-in option without `-q` in the help but got:\n%s", out.String())
+	}
+
+	if err := app.AddGlobalOptions("{--v|verbose}"); err != nil {
+		t.Errorf("Unexpected error for a global option using a built-in name: %s", err)
+	}
+	options = nil
+	if err := app.Run(args("app", "work", "-v")); err != nil || !reflect.DeepEqual(options["verbose"], &Result{}) {
+		t.Errorf("Expected the global verbose option but got %v (%v)!", options, err)
+	}
+}
+
+func TestOptionState(t *testing.T) {
+	var color, cache Tristate
+	var hasColor bool
+	app, out := testApp(&Command{
+		Name:      "build",
+		Signature: "{--color! : Colorize the output} {--cache!}",
+		Action: func(ctx *Context) {
+			color = ctx.OptionState("color")
+			cache = ctx.OptionState("cache")
+			hasColor = ctx.HasOption("color")
+		},
+	})
+
+	if err := app.Run(args("app", "build", "--no-color")); err != nil {
+		t.Errorf("Run failed with error: %s", err)
+	}
+	if color != TristateFalse || cache != TristateUnset {
+		t.Errorf("Expected `false` and `unset` states but got `%s` and `%s`!", color, cache)
+	}
+	if hasColor {
+		t.Errorf("A negated option should be missing from HasOption!")
+	}
+
+	app.Run(args("app", "build", "--no-color", "--color"))
+	if color != TristateTrue || !hasColor {
+		t.Errorf("Expected the last of --no-color and --color to win but got `%s`!", color)
+	}
+
+	out.Reset()
+	app.Run(args("app", "build", "--no-colour"))
+	if !strings.Contains(out.String(), "Did you mean `--no-color`?") {
+		t.Errorf("Expected a suggestion for the negated option but got: %s", out.String())
+	}
+
+	out.Reset()
+	app.Run(args("app", "build", "-h"))
+	if !strings.Contains(out.String(), "    --color|--no-color  Colorize the output\n") {
+		t.Errorf("Expected both forms of the option in the help but got:\n%s", out.String())
+	}
+}
+
+func TestOptionMap(t *testing.T) {
+	var labels map[string]string
+	app, out := testApp(&Command{
+		Name:      "deploy",
+		Signature: "{-l|label:map : Labels of the deployment}",
+		Action: func(ctx *Context) {
+			labels, _ = ctx.OptionMap("label")
+		},
+	})
+
+	if err := app.Run(args("app", "deploy", "--label", "env=prod", "-l", "tier=web")); err != nil {
+		t.Errorf("Run failed with error: %s", err)
+	}
+	if expected := map[string]string{"env": "prod", "tier": "web"}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected labels `%v` but got `%v`!", expected, labels)
+	}
+
+	out.Reset()
+	app.Run(args("app", "deploy", "-h"))
+	if !strings.Contains(out.String(), "-l, --label=KEY=VALUE  Labels of the deployment (multiple values allowed)\n") {
+		t.Errorf("Expected the map option in the help but got:\n%s", out.String())
+	}
+}
+
+func TestAbbreviations(t *testing.T) {
+	var options map[string]*Result
+	action := func(ctx *Context) {
+		options = ctx.Options
+	}
+	app, out := testApp(
+		&Command{Name: "migrate", Signature: "{--force} {--format=} {--color!}", Action: action},
+		&Command{Name: "make:model", Signature: "{name?}", Action: action},
+		&Command{Name: "make:migration", Action: action},
+		&Command{Name: "deploy", Signature: "{target?}", Action: action},
+		&Command{Name: "deploy:production", Action: action},
+		&Command{Name: "secret", Hidden: true, Action: action},
+	)
+
+	tests := []struct {
+		args    []string
+		command string
+		err     string
+	}{
+		{args("app", "mig", "--fo"), "", "The `--fo` option is ambiguous. Did you mean one of these: `--force`, `--format`?"},
+		{args("app", "mi", "--forc", "--form=json", "--no-col"), "migrate", ""},
+		{args("app", "make:mo"), "make:model", ""},
+		{args("app", "ma", "mod", "user"), "make:model", ""},
+		{args("app", "m"), "", "Command `m` is ambiguous! Did you mean one of these: `make`, `migrate`?"},
+		{args("app", "make:m"), "", "Command `make:m` is ambiguous! Did you mean one of these: `make:migration`, `make:model`?"},
+		{args("app", "dep", "prod"), "deploy", ""},
+		{args("app", "sec"), "", "Command `sec` was not found!"},
+	}
+
+	for i, test := range tests {
+		options = nil
+		out.Reset()
+		err := app.RunE(test.args)
+
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != test.err {
+			t.Errorf("Test #%d expected error `%s` but got `%s`!", i+1, test.err, msg)
+		}
+		if test.command != "" && options == nil {
+			t.Errorf("Test #%d expected the `%s` command to run!", i+1, test.command)
+		}
+	}
+
+	app.RunE(args("app", "mi", "--forc", "--form=json", "--no-col"))
+	expected := map[string]*Result{"force": &Result{}, "format": &Result{"json"}, "color": &Result{"false"}}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Expected options %v but got %v!", expected, options)
+	}
+
+	out.Reset()
+	app.RunE(args("app", "ma"))
+	if !strings.Contains(out.String(), "The commands of the `make` namespace are:") {
+		t.Errorf("Expected the namespace listing for a namespace prefix but got: %s", out.String())
+	}
+
+	out.Reset()
+	app.RunE(args("app", "mi", "--hel"))
+	if !strings.Contains(out.String(), "app migrate [options]") {
+		t.Errorf("Expected the help for an abbreviated help option but got: %s", out.String())
+	}
+
+	app.DisableAbbreviations = true
+	if err := app.RunE(args("app", "mig")); err == nil {
+		t.Errorf("Expected an error for an abbreviation when they are disabled!")
+	}
+}
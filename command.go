@@ -1,5 +1,7 @@
 package cli
 
+import "strings"
+
 type Command struct {
 	Name        string
 	Version     string
@@ -8,4 +10,108 @@ type Command struct {
 	Signature   string
 	Flags       FlagList
 	Action      Handler
+
+	// Action that can fail. It's used when Action is not set
+	ActionE HandlerE
+
+	// Child commands, i.e `migrate` for the `db` command
+	Commands map[string]*Command
+
+	// Hidden commands are not listed in the help and in the shell completion
+	Hidden bool
+
+	parent          *Command
+	segment         string
+	parsed          bool
+	parsedSignature string
+	parseErr        error
+}
+
+// Register child commands under this one. A name like `migrate:fresh` will
+// create the intermediate `migrate` namespace if it does not exist
+func (cmd *Command) AddCommand(children ...*Command) *Command {
+	if cmd.Commands == nil {
+		cmd.Commands = make(map[string]*Command, 0)
+	}
+	for _, child := range children {
+		insertCommand(cmd.Commands, cmd, child)
+	}
+	return cmd
+}
+
+// Get the full name of the command, i.e `db:migrate`
+func (cmd *Command) FullName() string {
+	if cmd.parent == nil {
+		return cmd.segment
+	}
+	return cmd.parent.FullName() + ":" + cmd.segment
+}
+
+// Get an argument or an option of the command by its name or alias, i.e to set
+// the completion callback: cmd.Flag("env").Complete = ...
+func (cmd *Command) Flag(name string) *Flag {
+	cmd.parse()
+
+	for _, flag := range cmd.Flags {
+		if flag.hasName(name) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// Check if the command only groups other commands
+func (cmd *Command) isNamespace() bool {
+	return cmd.Action == nil && cmd.ActionE == nil && len(cmd.Commands) > 0
+}
+
+// Insert the command into the tree. Names like `db:migrate` are split by `:`
+// and the missing namespaces are created on the way
+func insertCommand(commands map[string]*Command, parent *Command, c *Command) {
+	segments := strings.Split(c.Name, ":")
+	last := len(segments) - 1
+
+	for _, segment := range segments[:last] {
+		key := strings.ToLower(segment)
+		node, ok := commands[key]
+		if !ok {
+			node = &Command{Name: segment}
+			attachCommand(commands, parent, segment, node)
+		}
+		if node.Commands == nil {
+			node.Commands = make(map[string]*Command, 0)
+		}
+		parent, commands = node, node.Commands
+	}
+
+	attachCommand(commands, parent, segments[last], c)
+}
+
+// Attach the command to the parent under the given segment. If there is already
+// a command with the same name, its children are moved to the new one
+func attachCommand(commands map[string]*Command, parent *Command, segment string, c *Command) {
+	key := strings.ToLower(segment)
+	children := c.Commands
+
+	c.parent = parent
+	c.segment = segment
+	c.Commands = make(map[string]*Command, 0)
+	c.parse()
+
+	if old, ok := commands[key]; ok {
+		for k, child := range old.Commands {
+			c.Commands[k] = child
+			child.parent = c
+		}
+	}
+	commands[key] = c
+
+	for _, child := range children {
+		// Children added with cmd.AddCommand already have their namespaces resolved
+		if child.parent != nil {
+			attachCommand(c.Commands, c, child.segment, child)
+		} else {
+			insertCommand(c.Commands, c, child)
+		}
+	}
 }
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hidden argument used by the completion scripts: `app __complete <words...> <current word>`.
+// It writes the candidates for the current word, one per line
+const completeProtocol = "__complete"
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for {{name}}
+_{{func}}_completion() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null 2>&1; then
+        _get_comp_words_by_ref -n : cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$({{name}} __complete "${words[@]:1:cword}" 2>/dev/null)" -- "$cur"))
+
+    if declare -F __ltrim_colon_completions >/dev/null 2>&1; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _{{func}}_completion {{name}}
+`,
+	"zsh": `#compdef {{name}}
+_{{func}}() {
+    local -a completions
+    completions=(${(f)"$({{name}} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -a completions
+}
+compdef _{{func}} {{name}}
+`,
+	"fish": `# fish completion for {{name}}
+function __{{func}}_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    set -l current (commandline -ct)
+    {{name}} __complete $tokens "$current" 2>/dev/null
+end
+complete -c {{name}} -f -a '(__{{func}}_complete)'
+`,
+}
+
+// Command that writes the completion script for a shell, i.e `source <(app completion bash)`
+func completionCommand(app *App) *Command {
+	return &Command{
+		Name:        "completion",
+		Signature:   "{shell : The shell type (bash, zsh or fish)}",
+		Description: "Generate the shell completion script",
+		ActionE: func(ctx *Context) error {
+			shell, _ := ctx.Arguments["shell"].Str()
+			script, err := app.completionScript(shell)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(ctx.Writer, script)
+			return nil
+		},
+	}
+}
+
+// Generate the completion script for the given shell
+func (app *App) completionScript(shell string) (string, error) {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return "", fmt.Errorf("The `%s` shell is not supported (use bash, zsh or fish)!", shell)
+	}
+
+	fn := regexp.MustCompile("[^A-Za-z0-9_]").ReplaceAllString(app.Name, "_")
+	script = strings.Replace(script, "{{func}}", fn, -1)
+	script = strings.Replace(script, "{{name}}", app.Name, -1)
+
+	return script, nil
+}
+
+// Find the completion candidates for the last word. The words before it are used
+// to find the command, the flag that expects a value or the position of the argument
+func (app *App) complete(words []string) []string {
+	current := ""
+	if len(words) > 0 {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	cmd := app.Commands[""]
+	if name, pos := app.findFirstArgument(words); pos != -1 {
+		if cmd, words, _ = app.findCommand(words, name, pos); cmd == nil {
+			return nil
+		}
+	}
+
+	flags := app.commandFlags(cmd)
+
+	candidates := []string{}
+	positional, pending, terminated := completionState(words, flags)
+
+	switch {
+	case pending != nil:
+		candidates = pending.completions(current)
+	case strings.HasPrefix(current, "-") && !terminated:
+		for _, flag := range flags {
+			if flag.isArgument() {
+				continue
+			}
+			names := append(append([]string{}, flag.aliases...), flag.name)
+			for _, name := range append(names, flag.negatedNames()...) {
+				if len(name) == 1 {
+					candidates = append(candidates, "-"+name)
+				} else {
+					candidates = append(candidates, "--"+name)
+				}
+			}
+		}
+	default:
+		if cmd.Name == "" {
+			candidates = completeCommands(app.Commands, "")
+		} else if positional == 0 && len(cmd.Commands) > 0 {
+			candidates = completeCommands(cmd.Commands, cmd.FullName())
+		}
+
+		arg := flags.argument(positional)
+		if arg == nil && positional > 0 {
+			if last := flags.argument(positional - 1); last != nil && last.isArray() {
+				arg = last
+			}
+		}
+		if arg != nil {
+			candidates = append(candidates, arg.completions(current)...)
+		}
+	}
+
+	matches := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+// Count the positional words and find the option that waits for a value, if any.
+// It also tells if the options were terminated by `--`
+func completionState(words []string, flags FlagList) (int, *Flag, bool) {
+	positional := 0
+	terminated := false
+
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if terminated || !strings.HasPrefix(word, "-") {
+			positional++
+			continue
+		}
+		if word == "--" {
+			terminated = true
+			continue
+		}
+		if strings.Contains(word, "=") {
+			continue
+		}
+
+		var option *Flag
+		if strings.HasPrefix(word, "--") {
+			option = flags.option(word[2:])
+		} else {
+			option = flags.clusterValueOption(word[1:])
+		}
+		if option == nil || !option.acceptValue() {
+			continue
+		}
+		if i == len(words)-1 {
+			return positional, option, false
+		}
+		if !strings.HasPrefix(words[i+1], "-") {
+			i++
+		}
+	}
+
+	return positional, nil, terminated
+}
+
+// Get the names of the visible commands. Children are completed relative to
+// the parent namespace, i.e `migrate` for `db`, while top level commands
+// also include the namespaced names, i.e `db:migrate`
+func completeCommands(commands map[string]*Command, parent string) []string {
+	names := []string{}
+
+	for _, cmd := range commands {
+		if cmd.Name == "" || cmd.Hidden {
+			continue
+		}
+		names = append(names, cmd.segment)
+		if parent == "" {
+			for _, child := range collectCommands(cmd.Commands) {
+				names = append(names, child.FullName())
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
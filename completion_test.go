@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	deploy := echoCommand("deploy", "{target} {files?*} {-f|force} {-e|env=} {--color!} {--tag=*} {--format=json|yaml}")
+	deploy.Flag("target").Complete = func(prefix string) []string {
+		return []string{"production", "staging"}
+	}
+	deploy.Flag("env").Complete = func(prefix string) []string {
+		return []string{"local", "live"}
+	}
+
+	app, out := testApp(deploy, echoCommand("db:migrate", ""), echoCommand("db:seed", ""), &Command{
+		Name:   "secret",
+		Hidden: true,
+		Action: func(ctx *Context) {},
+	})
+
+	tests := []struct {
+		words    []string
+		expected []string
+	}{
+		{args(""), []string{"completion", "db", "db:migrate", "db:seed", "deploy", "help"}},
+		{args("d"), []string{"db", "db:migrate", "db:seed", "deploy"}},
+		{args("db", ""), []string{"migrate", "seed"}},
+		{args("deploy", ""), []string{"production", "staging"}},
+		{args("deploy", "--env", ""), []string{"local", "live"}},
+		{args("deploy", "--env", "l"), []string{"local", "live"}},
+		{args("deploy", "--env", "local", "s"), []string{"staging"}},
+		{args("deploy", "production", ""), []string{}},
+		{args("deploy", "--format", ""), []string{"json", "yaml"}},
+		{args("deploy", "-fe", ""), []string{"local", "live"}},
+		{args("deploy", "-felocal", ""), []string{"production", "staging"}},
+		{args("deploy", "--"), []string{"--help", "--quiet", "--verbose", "--ansi", "--no-ansi", "--force", "--env", "--color", "--no-color", "--tag", "--format"}},
+		{args("deploy", "-"), []string{"-h", "--help", "-q", "--quiet", "-v", "--verbose", "--ansi", "--no-ansi", "-f", "--force", "-e", "--env", "--color", "--no-color", "--tag", "--format"}},
+		{args("unknown", ""), []string{}},
+	}
+
+	for i, test := range tests {
+		out.Reset()
+		app.Run(append(args("app", "__complete"), test.words...))
+
+		got := []string{}
+		if out.Len() > 0 {
+			got = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Test #%d expected candidates %v but got %v!", i+1, test.expected, got)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	app, out := testApp()
+	app.Name = "my-app"
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out.Reset()
+		if err := app.Run(args("my-app", "completion", shell)); err != nil {
+			t.Errorf("Completion script for %s failed with error: %s", shell, err)
+		}
+		if !strings.Contains(out.String(), "my-app __complete") || !strings.Contains(out.String(), "my_app") {
+			t.Errorf("Completion script for %s does not call the complete protocol: %s", shell, out.String())
+		}
+	}
+
+	if err := app.Run(args("my-app", "completion", "powershell")); err == nil {
+		t.Errorf("Expected an error for an unsupported shell!")
+	}
+}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"io"
@@ -9,6 +8,51 @@ import (
 
 type Handler func(*Context)
 
+// Handler that can fail. The error is stored in the context and returned by App.Run
+type HandlerE func(*Context) error
+
+// Convert into a plain handler that stores the error in the context
+func (h HandlerE) handler() Handler {
+	return func(ctx *Context) {
+		if err := h(ctx); err != nil {
+			ctx.Fail(err)
+		}
+	}
+}
+
+// Where the value of an argument or option came from
+type Source int
+
+const (
+	SourceUnset Source = iota
+	SourceCommandLine
+	SourceEnv
+	SourceDefault
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceCommandLine:
+		return "command line"
+	case SourceEnv:
+		return "environment"
+	case SourceDefault:
+		return "default"
+	}
+	return "unset"
+}
+
+// How much output the user asked for with the built-in -q and -v options
+type Verbosity int
+
+const (
+	VerbosityQuiet Verbosity = iota
+	VerbosityNormal
+	VerbosityVerbose
+	VerbosityVeryVerbose
+	VerbosityDebug
+)
+
 // Context store the arguments and options and have attached helpers methods
 // to deal with console operations
 type Context struct {
@@ -17,8 +61,26 @@ type Context struct {
 	Reader    io.Reader
 	Writer    io.Writer
 
+	// Written by Secret when the reader is not a terminal and the answer can't be hidden
+	SecretWarning string
+
 	handlers []Handler
 	cursor   int
+	err      error
+
+	argumentSources map[string]Source
+	optionSources   map[string]Source
+	passthrough     []string
+
+	// Names of the negatable options, i.e {--color!}, see HasOption
+	negatable map[string]bool
+
+	// Shared by the prompts, so the buffered answers are not lost
+	input *lineReader
+
+	// Styles of the tags and whether the output is colored, see Context.Format
+	styles    map[string]Style
+	decorated bool
 }
 
 // Creates a new context
@@ -29,6 +91,7 @@ func newContext(reader io.Reader, writer io.Writer, args map[string]*Result, opt
 		Options:   opts,
 		Reader:    reader,
 		Writer:    writer,
+		styles:    defaultStyles(),
 	}
 }
 
@@ -51,6 +114,16 @@ func (ctx *Context) Run() {
 	}
 }
 
+// Mark the run as failed. The error will be returned by App.Run
+func (ctx *Context) Fail(err error) {
+	ctx.err = err
+}
+
+// Get the error of the failed handler
+func (ctx *Context) Err() error {
+	return ctx.err
+}
+
 // Set argument with values
 func (ctx *Context) SetArgument(key string, values ...string) {
 	if ctx.Arguments[key] == nil {
@@ -68,10 +141,11 @@ func (ctx *Context) SetOption(key string, values ...string) {
 	ctx.Options[key].Append(values...)
 }
 
-// Check if context has a specific option
+// Check if context has a specific option. Negatable options, i.e {--color!}, are
+// missing when they were negated with --no-color, see OptionState
 func (ctx *Context) HasOption(key string) bool {
-	if _, ok := ctx.Options[key]; ok {
-		return true
+	if result, ok := ctx.Options[key]; ok {
+		return !ctx.negatable[key] || result.State() == TristateTrue
 	}
 	return false
 }
@@ -84,6 +158,77 @@ func (ctx *Context) HasArgument(key string) bool {
 	return false
 }
 
+// Get where the value of the option came from: command line, environment variable or default value
+func (ctx *Context) OptionSource(key string) Source {
+	return ctx.optionSources[key]
+}
+
+// Get where the value of the argument came from: command line, environment variable or default value
+func (ctx *Context) ArgumentSource(key string) Source {
+	return ctx.argumentSources[key]
+}
+
+// Get the args given after `--` that were not used as arguments of the command,
+// i.e `docker run -it alpine` for `app exec -- docker run -it alpine`
+func (ctx *Context) Passthrough() []string {
+	return ctx.passthrough
+}
+
+// Get the key=value pairs of a map option, i.e {--label:map}
+func (ctx *Context) OptionMap(key string) (map[string]string, error) {
+	result, err := ctx.Option(key)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map()
+}
+
+// Get the state of a negatable option, i.e {--color!}: true for --color,
+// false for --no-color and unset when none of them was given
+func (ctx *Context) OptionState(key string) Tristate {
+	if result, ok := ctx.Options[key]; ok {
+		return result.State()
+	}
+	return TristateUnset
+}
+
+// Get the verbosity level: quiet for -q, normal by default, verbose for -v,
+// very verbose for -vv and debug for -vvv
+func (ctx *Context) Verbosity() Verbosity {
+	if ctx.HasOption("quiet") {
+		return VerbosityQuiet
+	}
+
+	level := VerbosityNormal
+	if verbose, ok := ctx.Options["verbose"]; ok {
+		level += Verbosity(verbose.Count())
+	}
+	if level > VerbosityDebug {
+		return VerbosityDebug
+	}
+	return level
+}
+
+// Check if the output should be suppressed, i.e -q
+func (ctx *Context) IsQuiet() bool {
+	return ctx.Verbosity() == VerbosityQuiet
+}
+
+// Check if the verbosity is at least verbose, i.e -v
+func (ctx *Context) IsVerbose() bool {
+	return ctx.Verbosity() >= VerbosityVerbose
+}
+
+// Check if the verbosity is at least very verbose, i.e -vv
+func (ctx *Context) IsVeryVerbose() bool {
+	return ctx.Verbosity() >= VerbosityVeryVerbose
+}
+
+// Check if the verbosity is debug, i.e -vvv
+func (ctx *Context) IsDebug() bool {
+	return ctx.Verbosity() == VerbosityDebug
+}
+
 // Get option from the context
 func (ctx *Context) Option(key string) (*Result, error) {
 	if _, ok := ctx.Options[key]; ok {
@@ -102,18 +247,10 @@ func (ctx *Context) Argument(key string) (*Result, error) {
 	return nil, errors.New("Argument not present!")
 }
 
-// Display a message then waits for an answer
-func (ctx *Context) Ask(msg string) string {
-	// Display the message
+// Display a message then waits for an answer. The answer is trimmed and the
+// error is io.EOF when the input ended or ErrInterrupted after Ctrl+C
+func (ctx *Context) Ask(msg string) (string, error) {
 	fmt.Fprint(ctx.Writer, msg)
 
-	// Create a new reader from ctx.Reader and wait for the response
-	reader := bufio.NewReader(ctx.Reader)
-	text, err := reader.ReadString('\n')
-
-	if err != nil {
-		return ""
-	}
-
-	return text
+	return ctx.readLine()
 }
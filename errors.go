@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors that know which exit code the process should end with
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// Returned when a signature can't be parsed. Pos is the byte offset of the flag
+// or of the character that caused the problem
+type SignatureError struct {
+	Signature string
+	Pos       int
+	Msg       string
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("%s (at position %d in `%s`)!", e.Msg, e.Pos, e.Signature)
+}
+
+// Returned when the os args don't match the signature of the command
+type UsageError struct {
+	Command string
+	Err     error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UsageError) ExitCode() int {
+	return 2
+}
+
+// Values of InvalidValueError.Expected for flags that got a value they can't take
+const (
+	ExpectNoValue     = "no value"
+	ExpectSingleValue = "a single value"
+	// For a key=value pair whose key was already given to a map flag, i.e {--label:map}
+	ExpectUniqueKey = "a unique key"
+)
+
+// Returned when the args contain an option that is not in the signature
+type UnknownOptionError struct {
+	// Name of the unknown option, without dashes
+	Name string
+	// The arg that contains the option, i.e `-vx` for the `x` option
+	Token    string
+	Position int
+	// Names of the options that are close to the unknown one
+	Suggestions []string
+}
+
+func (e *UnknownOptionError) Error() string {
+	return fmt.Sprintf("The `--%s` option does not exist.%s", e.Name, suggestionHint(e.Suggestions))
+}
+
+// Returned when an abbreviated long option matches more than one option, i.e `--ver`
+type AmbiguousOptionError struct {
+	// Name of the abbreviated option, without dashes
+	Name     string
+	Token    string
+	Position int
+	// Names of the options that start with the abbreviation
+	Candidates []string
+}
+
+func (e *AmbiguousOptionError) Error() string {
+	return fmt.Sprintf("The `--%s` option is ambiguous.%s", e.Name, suggestionHint(e.Candidates))
+}
+
+// Returned when required arguments are missing from the args
+type MissingArgumentError struct {
+	// The first missing argument
+	Flag *Flag
+	// All the missing arguments
+	Missing []*Flag
+	// Missing arguments have no token, so the position is always -1
+	Token    string
+	Position int
+}
+
+func (e *MissingArgumentError) Error() string {
+	names := []string{}
+	for _, flag := range e.Missing {
+		names = append(names, flag.name)
+	}
+	return fmt.Sprintf("Not enough arguments (missing: %s).", strings.Join(names, ", "))
+}
+
+// Returned when there are more args than arguments in the signature
+type TooManyArgumentsError struct {
+	// The last argument of the signature, nil if the signature has no arguments
+	Flag     *Flag
+	Token    string
+	Position int
+}
+
+func (e *TooManyArgumentsError) Error() string {
+	return "To many arguments!"
+}
+
+// Returned when an option that requires a value didn't get one
+type MissingValueError struct {
+	Flag     *Flag
+	Token    string
+	Position int
+}
+
+func (e *MissingValueError) Error() string {
+	return fmt.Sprintf("The `--%s` option requires a value!", e.Flag.name)
+}
+
+// Returned when a flag got a value it can't take: a value of a wrong type, a value
+// that is not one of the choices or a value for an option that doesn't accept one.
+// Values taken from environment variables or from the default values have the position -1
+type InvalidValueError struct {
+	Flag     *Flag
+	Token    string
+	Position int
+	Value    string
+	// What the flag expects, i.e `an integer`, `one of: json, yaml`, ExpectNoValue or ExpectSingleValue
+	Expected string
+	// The environment variable that held the value, if any
+	Env string
+}
+
+func (e *InvalidValueError) Error() string {
+	switch {
+	case e.Env != "":
+		return fmt.Sprintf("The `%s` environment variable of the `%s` %s expects %s.", e.Env, flagLabel(e.Flag), flagKind(e.Flag), e.Expected)
+	case e.Expected == ExpectNoValue:
+		return fmt.Sprintf("The `--%s` option does not accept a value!", e.Flag.name)
+	case e.Expected == ExpectSingleValue:
+		return fmt.Sprintf("The `--%s` option does not accept an array of values!", e.Flag.name)
+	case e.Expected == ExpectUniqueKey:
+		key := strings.SplitN(e.Value, "=", 2)[0]
+		return fmt.Sprintf("The `%s` key is given more than once to the `%s` %s!", key, flagLabel(e.Flag), flagKind(e.Flag))
+	}
+	return fmt.Sprintf("The `%s` %s expects %s.", flagLabel(e.Flag), flagKind(e.Flag), e.Expected)
+}
+
+// Get the name of the flag as it's written in the args, i.e `--queue` or `file`
+func flagLabel(flag *Flag) string {
+	if flag.isArgument() {
+		return flag.name
+	}
+	return "--" + flag.name
+}
+
+// Get the kind of the flag for the error messages
+func flagKind(flag *Flag) string {
+	if flag.isArgument() {
+		return "argument"
+	}
+	return "option"
+}
+
+// Returned when there is no registered command with the given name
+type CommandNotFoundError struct {
+	Name string
+	// Names of the commands that are close to the given name
+	Suggestions []string
+}
+
+func (e *CommandNotFoundError) Error() string {
+	return fmt.Sprintf("Command `%s` was not found!%s", e.Name, suggestionHint(e.Suggestions))
+}
+
+func (e *CommandNotFoundError) ExitCode() int {
+	return 127
+}
+
+// Returned when an abbreviated command name matches more than one command
+type AmbiguousCommandError struct {
+	Name string
+	// Full names of the commands that start with the abbreviation
+	Candidates []string
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	return fmt.Sprintf("Command `%s` is ambiguous!%s", e.Name, suggestionHint(e.Candidates))
+}
+
+func (e *AmbiguousCommandError) ExitCode() int {
+	return 127
+}
+
+// Returned when the action of a command fails
+type ActionError struct {
+	Command string
+	Err     error
+}
+
+func (e *ActionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// The exit code of the wrapped error if it has one, 1 otherwise
+func (e *ActionError) ExitCode() int {
+	var coder ExitCoder
+	if errors.As(e.Err, &coder) {
+		return coder.ExitCode()
+	}
+	return 1
+}
+
+type exitError struct {
+	message string
+	code    int
+}
+
+func (e *exitError) Error() string {
+	return e.message
+}
+
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// Creates an error with a custom exit code, i.e: return cli.Exit("Deploy failed!", 3)
+func Exit(message string, code int) ExitCoder {
+	return &exitError{message: message, code: code}
+}
+
+// Get the exit code for an error returned by App.Run.
+// Nil errors will return 0 and errors that are not an ExitCoder will return 1
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return 1
+}
@@ -1,5 +1,10 @@
 package cli
 
+import (
+	"fmt"
+	"strings"
+)
+
 const (
 	argumentFlag = iota
 	optionFlag
@@ -17,16 +22,30 @@ const (
 	valueRequired = 16
 	valueOptional = 32
 	valueArray    = 64
+	counter       = 128
+	negatable     = 256
 )
 
 /** Option flags **/
 
+// Callback that returns the shell completion candidates for the value of a flag
+type CompletionFunc func(prefix string) []string
+
 type Flag struct {
 	kind        int8
 	name        string
-	options     int8
+	aliases     []string
+	options     int16
 	description string
 	value       string
+	valueType   string
+	choices     []string
+
+	// Dynamic completion for the values of the flag
+	Complete CompletionFunc
+
+	// Environment variable used when the flag is missing from the command line
+	Env string
 }
 
 // Check if the flag is an argument
@@ -52,6 +71,16 @@ func (f Flag) acceptValue() bool {
 	return f.isOptional() || f.isRequired()
 }
 
+// Check if the option counts its occurrences, i.e -vvv
+func (f Flag) isCounter() bool {
+	return !f.isArgument() && f.options&counter == counter
+}
+
+// Check if the option has a --no-<name> counterpart, i.e {--color!}
+func (f Flag) isNegatable() bool {
+	return !f.isArgument() && f.options&negatable == negatable
+}
+
 // Check if argument or option accepts more than one value
 func (f Flag) isArray() bool {
 	if f.isArgument() {
@@ -76,6 +105,105 @@ func (f Flag) isRequired() bool {
 	return f.options&valueRequired == valueRequired
 }
 
+// Check if the flag can be referred by the given name or by one of its aliases
+func (f Flag) hasName(name string) bool {
+	if f.name == name {
+		return true
+	}
+	for _, alias := range f.aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the name is the negated form of a long name, i.e `no-color` for {--color!}
+func (f Flag) hasNegatedName(name string) bool {
+	for _, negated := range f.negatedNames() {
+		if negated == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the --no-<name> forms of the long names of a negatable option
+func (f Flag) negatedNames() []string {
+	if !f.isNegatable() {
+		return nil
+	}
+
+	names := []string{}
+	for _, name := range append(append([]string{}, f.aliases...), f.name) {
+		if len(name) > 1 {
+			names = append(names, "no-"+name)
+		}
+	}
+	return names
+}
+
+// Get a copy of the option without the aliases used by the given flags, i.e `--quiet`
+// without `-q` next to {-q|queue}. It's nil when the name or a negated name is used
+func (f *Flag) withoutNames(flags FlagList) *Flag {
+	for _, name := range append([]string{f.name}, f.negatedNames()...) {
+		if flags.option(name) != nil {
+			return nil
+		}
+	}
+
+	option := *f
+	option.aliases = nil
+	for _, alias := range f.aliases {
+		if flags.option(alias) == nil {
+			option.aliases = append(option.aliases, alias)
+		}
+	}
+	return &option
+}
+
+// Get the name, the aliases and the negated names of the flag
+func (f Flag) names() []string {
+	names := append([]string{f.name}, f.aliases...)
+	return append(names, f.negatedNames()...)
+}
+
+// Check if the value matches the type and the choices of the flag
+func (f Flag) checkValue(value string) error {
+	if f.valueType != "" {
+		if err := valueTypes[f.valueType].check(value); err != nil {
+			return err
+		}
+	}
+
+	if len(f.choices) == 0 {
+		return nil
+	}
+	for _, choice := range f.choices {
+		if choice == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("`%s` is not a valid choice", value)
+}
+
+// Describe what kind of value the flag expects, i.e `an integer` or `one of: json, yaml`
+func (f Flag) expects() string {
+	if len(f.choices) > 0 {
+		return "one of: " + strings.Join(f.choices, ", ")
+	}
+	return valueTypes[f.valueType].expects
+}
+
+// Get the shell completion candidates for a value of the flag.
+// The choices are used when there is no completion callback
+func (f Flag) completions(prefix string) []string {
+	if f.Complete == nil {
+		return f.choices
+	}
+	return f.Complete(prefix)
+}
+
 // Get the name of the argument/option
 func (f Flag) String() string {
 	return f.name
@@ -103,6 +231,16 @@ func (fl *FlagList) requiredArgs() []string {
 	return flags
 }
 
+// Check if there is at least one option in the list
+func (fl *FlagList) hasOptions() bool {
+	for _, flag := range *fl {
+		if !flag.isArgument() {
+			return true
+		}
+	}
+	return false
+}
+
 // Find the argument number `pos` from the list of the flags. Options will be skipped
 func (fl *FlagList) argument(pos int) *Flag {
 	current := 0
@@ -119,12 +257,62 @@ func (fl *FlagList) argument(pos int) *Flag {
 	return nil
 }
 
-// Find option by name. Arguments will be skipped
+// Find an argument or an option by name, alias or negated name
+func (fl *FlagList) find(name string, argument bool) *Flag {
+	for _, flag := range *fl {
+		if flag.isArgument() == argument && (flag.hasName(name) || flag.hasNegatedName(name)) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// Find option by name, alias or negated name. Arguments will be skipped
 func (fl *FlagList) option(opt string) *Flag {
 	for _, flag := range *fl {
-		if !flag.isArgument() && flag.name == opt {
+		if !flag.isArgument() && (flag.hasName(opt) || flag.hasNegatedName(opt)) {
 			return flag
 		}
 	}
 	return nil
 }
+
+// Find the option of a short cluster, i.e `-vxf`, that waits for the next arg
+// as its value. Options with the value attached, i.e `-ofile.txt`, don't wait
+func (fl *FlagList) clusterValueOption(cluster string) *Flag {
+	names := []rune(cluster)
+
+	for i, c := range names {
+		option := fl.option(string(c))
+		if option == nil {
+			return nil
+		}
+		if option.acceptValue() {
+			if i < len(names)-1 {
+				return nil
+			}
+			return option
+		}
+	}
+	return nil
+}
+
+// Find the long names of the options that start with the prefix, one per option,
+// i.e `verbose` and `version` for `ver`
+func (fl *FlagList) optionPrefix(prefix string) []string {
+	names := []string{}
+
+	for _, flag := range *fl {
+		if flag.isArgument() {
+			continue
+		}
+		for _, name := range flag.names() {
+			if len(name) > 1 && strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Command that displays the help of another command, i.e `app help db:migrate` or `app help db migrate`
+func helpCommand(app *App) *Command {
+	return &Command{
+		Name:        "help",
+		Signature:   "{command_name?* : The command name}",
+		Description: "Display help for a command",
+		ActionE: func(ctx *Context) error {
+			names, err := ctx.Argument("command_name")
+			if err != nil {
+				app.renderHome()
+				return nil
+			}
+
+			path := names.StrSlice()
+			cmd, rest, err := app.findCommand(path, path[0], 0)
+			if err != nil {
+				return err
+			}
+			if len(rest) > 0 {
+				return app.commandNotFound(cmd.FullName() + ":" + rest[0])
+			}
+
+			app.renderHelp(cmd)
+			return nil
+		},
+	}
+}
+
+// Write the help of the command: description, usage line, arguments and options
+func (app *App) renderHelp(cmd *Command) {
+	if cmd.Name == "" {
+		app.renderHome()
+		return
+	}
+	if cmd.isNamespace() {
+		app.renderNamespace(cmd)
+		return
+	}
+
+	flags := app.commandFlags(cmd)
+
+	if cmd.Description != "" {
+		fmt.Fprintln(app.Writer, "Description:")
+		fmt.Fprintf(app.Writer, "\t%s\n\n", cmd.Description)
+	}
+
+	fmt.Fprintln(app.Writer, "Usage:")
+	fmt.Fprintf(app.Writer, "\t%s\n", usageLine(app.Name, cmd, flags))
+
+	arguments := [][2]string{}
+	options := [][2]string{}
+	for _, flag := range flags {
+		if flag.isArgument() {
+			arguments = append(arguments, [2]string{flag.name, flagHelp(flag)})
+		} else {
+			options = append(options, [2]string{optionSynopsis(flag), flagHelp(flag)})
+		}
+	}
+
+	if len(arguments) > 0 {
+		fmt.Fprintln(app.Writer, "\nArguments:")
+		writeColumns(app, arguments)
+	}
+
+	if len(options) > 0 {
+		fmt.Fprintln(app.Writer, "\nOptions:")
+		writeColumns(app, options)
+	}
+
+	if len(cmd.Commands) > 0 {
+		fmt.Fprintln(app.Writer, "\nThe sub-commands are:")
+		app.listCommands(cmd.Commands)
+	}
+}
+
+// Build the usage line, i.e: `app build [options] [--] <file> [<out>...]`
+func usageLine(program string, cmd *Command, flags FlagList) string {
+	parts := []string{program, cmd.FullName()}
+
+	if flags.hasOptions() {
+		parts = append(parts, "[options]")
+		if flags.argument(0) != nil {
+			parts = append(parts, "[--]")
+		}
+	}
+
+	for _, flag := range flags {
+		if !flag.isArgument() {
+			continue
+		}
+		arg := "<" + flag.name + ">"
+		if flag.isArray() {
+			arg += "..."
+		}
+		if flag.isOptional() {
+			arg = "[" + arg + "]"
+		}
+		parts = append(parts, arg)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Build the names column of an option, i.e: `-q, --queue[=QUEUE]`
+func optionSynopsis(flag *Flag) string {
+	names := []string{}
+
+	for _, name := range append(append([]string{}, flag.aliases...), flag.name) {
+		if len(name) == 1 {
+			names = append(names, "-"+name)
+		} else {
+			names = append(names, "--"+name)
+		}
+	}
+
+	// Show both forms of the negatable options, i.e `--color|--no-color`
+	if negated := flag.negatedNames(); len(negated) > 0 {
+		names[len(names)-1] += "|--" + negated[len(negated)-1]
+	}
+
+	// Keep the long names aligned when there is no short alias
+	synopsis := strings.Join(names, ", ")
+	if !strings.HasPrefix(synopsis, "-") || strings.HasPrefix(synopsis, "--") {
+		synopsis = "    " + synopsis
+	}
+
+	switch {
+	case flag.valueType == "map":
+		synopsis += "=KEY=VALUE"
+	case flag.isRequired():
+		synopsis += "=" + strings.ToUpper(flag.name)
+	case flag.isOptional():
+		synopsis += "[=" + strings.ToUpper(flag.name) + "]"
+	}
+
+	return synopsis
+}
+
+// Build the description column of a flag with the default value and the array marker
+func flagHelp(flag *Flag) string {
+	help := flag.description
+
+	if flag.value != "" {
+		help += fmt.Sprintf(" [default: %q]", flag.value)
+	}
+
+	if len(flag.choices) > 0 {
+		help += fmt.Sprintf(" [choices: %s]", strings.Join(flag.choices, ", "))
+	}
+
+	if flag.Env != "" {
+		help += fmt.Sprintf(" [env: %s]", flag.Env)
+	}
+
+	if flag.isArray() {
+		help += " (multiple values allowed)"
+	}
+
+	if flag.isCounter() {
+		help += " (can be repeated)"
+	}
+
+	return strings.TrimSpace(help)
+}
+
+// Write two aligned columns
+func writeColumns(app *App, rows [][2]string) {
+	width := 0
+	for _, row := range rows {
+		if len(row[0]) > width {
+			width = len(row[0])
+		}
+	}
+
+	for _, row := range rows {
+		line := fmt.Sprintf("\t%-*s  %s", width, row[0], row[1])
+		fmt.Fprintln(app.Writer, strings.TrimRight(line, " "))
+	}
+}
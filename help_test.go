@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandHelp(t *testing.T) {
+	app, out := testApp(
+		&Command{
+			Name:        "db:migrate",
+			Signature:   "{dir : The migrations directory} {files?* : Migration files} {-f|force : Skip confirmation} {--step=1 : Number of steps} {-t|tag=*}",
+			Description: "Run the migrations",
+			Action:      func(ctx *Context) {},
+		},
+	)
+
+	expected := `Description:
+	Run the migrations
+
+Usage:
+	app db:migrate [options] [--] <dir> [<files>...]
+
+Arguments:
+	dir    The migrations directory
+	files  Migration files (multiple values allowed)
+
+Options:
+	-h, --help            Display help for the given command
+	-q, --quiet           Do not output any message
+	-v, --verbose         Increase the verbosity of messages: -v for verbose, -vv for very verbose and -vvv for debug (can be repeated)
+	    --ansi|--no-ansi  Force the colors of the output, or disable them with --no-ansi
+	-f, --force           Skip confirmation
+	    --step[=STEP]     Number of steps [default: "1"]
+	-t, --tag[=TAG]       (multiple values allowed)
+`
+
+	for i, argv := range [][]string{
+		args("app", "db:migrate", "--help"),
+		args("app", "db", "migrate", "-h"),
+		args("app", "help", "db:migrate"),
+		args("app", "help", "db", "migrate"),
+	} {
+		out.Reset()
+		if err := app.Run(argv); err != nil {
+			t.Errorf("Test #%d failed with error: %s", i+1, err)
+		}
+		if out.String() != expected {
+			t.Errorf("Test #%d expected help:\n%s\nbut got:\n%s", i+1, expected, out.String())
+		}
+	}
+
+	out.Reset()
+	app.AddCommand(func(*App) *Command {
+		return &Command{Name: "export", Signature: "{--format=json|yaml : Output format}", Action: func(ctx *Context) {}}
+	})
+	app.Run(args("app", "export", "-h"))
+	if !strings.Contains(out.String(), "--format[=FORMAT]  Output format [default: \"json\"] [choices: json, yaml]\n") {
+		t.Errorf("Expected the choices in the help but got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := app.Run(args("app", "help", "db", "rollback")); err == nil || err.Error() != "Command `db:rollback` was not found!" {
+		t.Errorf("Expected command not found error for help on unknown command but got `%v`!", err)
+	}
+}
+
+func TestHelpOptionAsValue(t *testing.T) {
+	var pattern string
+	app, out := testApp(&Command{
+		Name:      "grep",
+		Signature: "{--pattern=+} {file}",
+		Action: func(ctx *Context) {
+			res, _ := ctx.Option("pattern")
+			pattern, _ = res.Str()
+		},
+	})
+
+	if err := app.Run(args("app", "grep", "--pattern", "-h", "x.txt")); err != nil {
+		t.Errorf("Run failed with error: %s", err)
+	}
+	if pattern != "-h" || out.String() != "" {
+		t.Errorf("Expected `-h` as the value of the pattern but got `%s`! Output: %s", pattern, out.String())
+	}
+
+	if err := app.Run(args("app", "grep", "--unknown", "-h")); err == nil {
+		t.Errorf("Expected an error for an unknown option next to -h!")
+	}
+}
+
+func TestHelpOptionGivesWay(t *testing.T) {
+	var host string
+	app, out := testApp()
+	err := app.AddCommand(func(*App) *Command {
+		return &Command{
+			Name:      "serve",
+			Signature: "{-h|host=}",
+			Action: func(ctx *Context) {
+				res, _ := ctx.Option("host")
+				host, _ = res.Str()
+			},
+		}
+	})
+	if err != nil {
+		t.Errorf("Unexpected error for a command using `-h`: %s", err)
+	}
+
+	if err := app.Run(args("app", "serve", "-h", "localhost")); err != nil || host != "localhost" {
+		t.Errorf("Expected `-h` to be the host option but got `%s` (%v)!", host, err)
+	}
+
+	out.Reset()
+	app.Run(args("app", "serve", "--help"))
+	if !strings.Contains(out.String(), "    --help") || !strings.Contains(out.String(), "-h, --host") {
+		t.Errorf("Expected the help without `-h` but got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Returned by the prompts when the user presses Ctrl+C while an answer is expected
+var ErrInterrupted = errors.New("The input was interrupted!")
+
+// Reads the answers of the prompts line by line. It's shared by all the prompts of a
+// context, so the data buffered for one prompt is not lost for the next ones
+type lineReader struct {
+	reader *bufio.Reader
+	// The read that was left waiting for a line after an interrupt
+	pending chan readResult
+	// Starts listening for the interrupts, the returned func stops it
+	interrupts func() (<-chan os.Signal, func())
+}
+
+type readResult struct {
+	line string
+	err  error
+}
+
+// Creates a new line reader that listens for os.Interrupt while reading
+func newLineReader(reader io.Reader) *lineReader {
+	return &lineReader{
+		reader:     bufio.NewReader(reader),
+		interrupts: notifyInterrupts,
+	}
+}
+
+// Relay the os.Interrupt signals instead of stopping the process
+func notifyInterrupts() (<-chan os.Signal, func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	return signals, func() {
+		signal.Stop(signals)
+	}
+}
+
+// Read the next line with its line ending. The last line can miss the line ending,
+// then io.EOF is returned. An interrupt returns ErrInterrupted, but the line
+// that was being read is kept for the next call
+func (lr *lineReader) readLine() (string, error) {
+	if lr.pending == nil {
+		lr.pending = make(chan readResult, 1)
+		go func(result chan<- readResult) {
+			line, err := lr.reader.ReadString('\n')
+			result <- readResult{line, err}
+		}(lr.pending)
+	}
+
+	interrupts, stop := lr.interrupts()
+	defer stop()
+
+	select {
+	case result := <-lr.pending:
+		lr.pending = nil
+		if result.err == io.EOF && result.line != "" {
+			result.err = nil
+		}
+		return result.line, result.err
+	case <-interrupts:
+		return "", ErrInterrupted
+	}
+}
+
+// Read the next answer from the context reader, without the spaces around it. The reader
+// is wrapped into a line reader on the first prompt, and that one is used by all the other prompts
+func (ctx *Context) readLine() (string, error) {
+	line, err := ctx.lineReader().readLine()
+	return strings.TrimSpace(line), err
+}
+
+// Read the next answer without the line ending, but with the spaces, i.e for the secrets
+func (ctx *Context) readRawLine() (string, error) {
+	line, err := ctx.lineReader().readLine()
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), err
+}
+
+// Get the line reader shared by the prompts
+func (ctx *Context) lineReader() *lineReader {
+	if ctx.input == nil {
+		ctx.input = newLineReader(ctx.Reader)
+	}
+	return ctx.input
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestAsk(t *testing.T) {
+	ctx, out := promptContext("  Johnny \r\nsecond answer\nlast")
+
+	for i, expected := range []string{"Johnny", "second answer", "last"} {
+		answer, err := ctx.Ask("Name: ")
+		if err != nil || answer != expected {
+			t.Errorf("Test #%d expected answer `%s` but got `%s` (%v)!", i+1, expected, answer, err)
+		}
+	}
+
+	if answer, err := ctx.Ask("Name: "); err != io.EOF || answer != "" {
+		t.Errorf("Expected EOF error after the last answer but got `%s` (%v)!", answer, err)
+	}
+
+	if out.String() != "Name: Name: Name: Name: " {
+		t.Errorf("Expected the message before every answer but got `%s`!", out.String())
+	}
+}
+
+func TestInterruptedInput(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	ctx, _ := promptContext("")
+	ctx.Reader = reader
+
+	signals := make(chan os.Signal, 1)
+	ctx.input = newLineReader(reader)
+	ctx.input.interrupts = func() (<-chan os.Signal, func()) {
+		return signals, func() {}
+	}
+
+	signals <- os.Interrupt
+	if _, err := ctx.Ask("Name: "); err != ErrInterrupted {
+		t.Errorf("Expected interrupted error but got `%v`!", err)
+	}
+
+	// The read that was waiting during the interrupt gets the next line
+	go writer.Write([]byte("Johnny\n"))
+	if answer, err := ctx.Ask("Name: "); err != nil || answer != "Johnny" {
+		t.Errorf("Expected `Johnny` after the interrupt but got `%s` (%v)!", answer, err)
+	}
+}
@@ -2,15 +2,40 @@ package cli
 
 import (
 	"errors"
-	"fmt"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+var negativeNumber = regexp.MustCompile(`^-(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
 type matcher struct {
 	arguments map[string]*Result
 	options   map[string]*Result
 	flags     FlagList
 
+	// Where the values of the arguments and options came from
+	argumentSources map[string]Source
+	optionSources   map[string]Source
+	lookupEnv       func(string) (string, bool)
+
+	// Maximum edits for the suggestions of unknown options, 0 disables them
+	suggestionThreshold int
+
+	// Positions of the args in the os args, used by the errors
+	positions []int
+
+	// Resolve the unique prefixes of the long options, i.e `--verb` for `--verbose`
+	abbreviations bool
+
+	// Option that skips the check of the missing arguments when given, i.e --help
+	helpOption string
+
+	// Args after `--` that didn't fit into the arguments of the signature
+	passthrough []string
+	terminated  bool
+
 	//
 	args   []string
 	cursor int
@@ -23,6 +48,10 @@ func newMatcher(args []string, flags FlagList) *matcher {
 		flags:     flags,
 		args:      args,
 		cursor:    0,
+
+		argumentSources: make(map[string]Source, 0),
+		optionSources:   make(map[string]Source, 0),
+		lookupEnv:       os.LookupEnv,
 	}
 
 	return matcher
@@ -70,6 +99,14 @@ func (m *matcher) current() string {
 	return m.args[m.cursor]
 }
 
+// Get the position of the arg in the os args
+func (m *matcher) position(cursor int) int {
+	if cursor < len(m.positions) {
+		return m.positions[cursor]
+	}
+	return cursor
+}
+
 // Look ahead and get the next element without moving the curosr
 func (m *matcher) peek() (string, error) {
 	if m.cursor+1 >= len(m.args) {
@@ -85,6 +122,24 @@ func (m *matcher) match() error {
 	for m.hasNext() {
 		arg := m.current()
 		switch {
+		case m.terminated: // Everything after `--` is an argument
+			if m.acceptsArgument() {
+				if err := m.matchArgument(arg); err != nil {
+					return err
+				}
+			} else {
+				m.passthrough = append(m.passthrough, arg)
+			}
+		case arg == "--": // End of the options
+			m.terminated = true
+		case arg == "-": // A lone dash is an argument, i.e stdin
+			if err := m.matchArgument(arg); err != nil {
+				return err
+			}
+		case m.isNegativeNumber(arg): // We matched a negative number argument
+			if err := m.matchArgument(arg); err != nil {
+				return err
+			}
 		case strings.HasPrefix(arg, "-"): // We matched an option
 			if err := m.matchOption(arg); err != nil {
 				return err
@@ -102,8 +157,19 @@ func (m *matcher) match() error {
 	return m.validate()
 }
 
-// Validate arguments so the matcher will return error if requiredArgs != foundArgs
+// Validate arguments so the matcher will return error if a required argument is missing.
+// Missing flags are filled from the environment variables, then from the default values
 func (m *matcher) validate() error {
+	for name := range m.arguments {
+		m.argumentSources[name] = SourceCommandLine
+	}
+	for name := range m.options {
+		m.optionSources[name] = SourceCommandLine
+	}
+
+	if err := m.applyEnv(); err != nil {
+		return err
+	}
 
 	// Set arguments with default value
 	for _, flag := range m.flags {
@@ -112,6 +178,7 @@ func (m *matcher) validate() error {
 		}
 		if _, ok := m.arguments[flag.name]; !ok && flag.value != "" {
 			m.setArgument(flag.name, flag.value)
+			m.argumentSources[flag.name] = SourceDefault
 		}
 	}
 
@@ -122,72 +189,240 @@ func (m *matcher) validate() error {
 		}
 		if _, ok := m.options[flag.name]; !ok && flag.value != "" {
 			m.setOption(flag.name, flag.value)
+			m.optionSources[flag.name] = SourceDefault
 		}
 	}
-	requiredArgs := m.flags.requiredArgs()
 
-	if len(requiredArgs) <= len(m.arguments) {
-		return nil
+	var missing []*Flag
+	for _, arg := range m.flags.requiredArgs() {
+		if _, ok := m.arguments[arg]; !ok {
+			missing = append(missing, m.flags.find(arg, true))
+		}
+	}
+	if _, help := m.options[m.helpOption]; len(missing) > 0 && !help {
+		return m.fail(&MissingArgumentError{Flag: missing[0], Missing: missing, Position: -1})
 	}
 
-	var missing []string
-	for _, arg := range requiredArgs {
-		if _, ok := m.arguments[arg]; !ok {
-			missing = append(missing, arg)
+	return m.validateValues()
+}
+
+// Set the flags that are missing from the command line with the values of their
+// environment variables, i.e {--queue=redis @QUEUE_NAME}. Empty variables are ignored
+func (m *matcher) applyEnv() error {
+	for _, flag := range m.flags {
+		if flag.Env == "" {
+			continue
+		}
+
+		value, ok := m.lookupEnv(flag.Env)
+		if !ok || value == "" {
+			continue
+		}
+
+		if flag.isArgument() {
+			if _, ok := m.arguments[flag.name]; !ok {
+				m.setArgument(flag.name, value)
+				m.argumentSources[flag.name] = SourceEnv
+			}
+			continue
+		}
+
+		if _, ok := m.options[flag.name]; ok {
+			continue
+		}
+
+		// Counters are set to the number of the variable, i.e VERBOSITY=2 for -vv
+		if flag.isCounter() {
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				return m.fail(&InvalidValueError{Flag: flag, Position: -1, Value: value, Expected: "an integer", Env: flag.Env})
+			}
+			for i := 0; i < count; i++ {
+				m.setOption(flag.name, "1")
+			}
+			if count > 0 {
+				m.optionSources[flag.name] = SourceEnv
+			}
+			continue
+		}
+
+		// Options without values are switched on or off by the variable
+		if !flag.acceptValue() {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return m.fail(&InvalidValueError{Flag: flag, Position: -1, Value: value, Expected: "a boolean", Env: flag.Env})
+			}
+			if flag.isNegatable() {
+				m.options[flag.name] = &Result{strconv.FormatBool(enabled)}
+				m.optionSources[flag.name] = SourceEnv
+			} else if enabled {
+				m.setOption(flag.name)
+				m.optionSources[flag.name] = SourceEnv
+			}
+			continue
+		}
+
+		m.setOption(flag.name, value)
+		m.optionSources[flag.name] = SourceEnv
+	}
+
+	return nil
+}
+
+// Check that the values of typed flags, i.e {port:int}, can be converted
+// and that the flags with choices, i.e {--format=json|yaml}, got a valid one.
+// The values from the command line are checked while they are matched
+func (m *matcher) validateValues() error {
+	for _, flag := range m.flags {
+		if flag.valueType == "" && len(flag.choices) == 0 {
+			continue
+		}
+
+		values, sources := m.options, m.optionSources
+		if flag.isArgument() {
+			values, sources = m.arguments, m.argumentSources
+		}
+
+		if _, ok := values[flag.name]; !ok || sources[flag.name] == SourceCommandLine {
+			continue
+		}
+
+		for _, value := range *values[flag.name] {
+			if flag.checkValue(value) != nil {
+				err := &InvalidValueError{Flag: flag, Position: -1, Value: value, Expected: flag.expects()}
+				if sources[flag.name] == SourceEnv {
+					err.Env = flag.Env
+				}
+				return m.fail(err)
+			}
 		}
 	}
-	return m.fail("Not enough arguments (missing: %s).", strings.Join(missing, ", "))
+
+	return nil
+}
+
+// Check the value of a flag that was given in the command line.
+// The keys of the map flags, i.e {--label:map}, can be given only once
+func (m *matcher) checkValue(flag *Flag, value string) error {
+	expected := ""
+	if flag.checkValue(value) != nil {
+		expected = flag.expects()
+	} else if flag.valueType == "map" && m.hasKey(flag, value) {
+		expected = ExpectUniqueKey
+	}
+
+	if expected != "" {
+		return m.fail(&InvalidValueError{
+			Flag:     flag,
+			Token:    m.current(),
+			Position: m.position(m.cursor),
+			Value:    value,
+			Expected: expected,
+		})
+	}
+	return nil
+}
+
+// Check if the key of the pair was already given to the map flag
+func (m *matcher) hasKey(flag *Flag, pair string) bool {
+	values := m.options[flag.name]
+	if flag.isArgument() {
+		values = m.arguments[flag.name]
+	}
+	if values == nil {
+		return false
+	}
+
+	key, _, _ := splitPair(pair)
+	for _, value := range *values {
+		if existing, _, _ := splitPair(value); existing == key {
+			return true
+		}
+	}
+	return false
 }
 
 // Parses options like --opt, --opt=val --opt val according to the defined flags
 func (m *matcher) matchOption(arg string) error {
-	value := ""
-	isShort := false
-
-	if strings.HasPrefix(arg, "--") {
-		arg = arg[2:]
-	} else {
-		arg = arg[1:]
-		isShort = true
+	if !strings.HasPrefix(arg, "--") {
+		return m.matchShortOptions(arg[1:])
 	}
 
+	arg = arg[2:]
+	value := ""
 	if strings.Contains(arg, "=") {
-		parts := strings.Split(arg, "=")
+		parts := strings.SplitN(arg, "=", 2)
 		arg = parts[0]
 		value = parts[1]
 	}
 
-	if isShort {
-		if len(arg) > 1 {
-			// -abc=something is strange
-			if value != "" {
-				return m.fail("The `-%s` options cannot accept values!", arg)
+	if m.flags.option(arg) == nil && m.abbreviations && arg != "" {
+		names := m.flags.optionPrefix(arg)
+		if len(names) > 1 {
+			for i := range names {
+				names[i] = "--" + names[i]
 			}
+			return m.fail(&AmbiguousOptionError{Name: arg, Token: m.current(), Position: m.position(m.cursor), Candidates: names})
+		}
+		if len(names) == 1 {
+			arg = names[0]
+		}
+	}
 
-			// -abc should contains 3 options
-			for _, c := range strings.Split(arg, "") {
-				err := m.matchOption("-" + c)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
+	return m.matchFlag(arg, value)
+}
+
+// Parses short options the way getopt does: -abc contains 3 options and the
+// first one that accepts a value takes the rest of the cluster as its value,
+// i.e -ofile.txt or -n5, or the next arg when nothing is left, i.e -vxf archive.tar
+func (m *matcher) matchShortOptions(cluster string) error {
+	names := []rune(cluster)
+
+	for i, c := range names {
+		option := m.flags.option(string(c))
+		if option == nil {
+			return m.unknownOption(string(c))
+		}
+
+		rest := string(names[i+1:])
+		if option.acceptValue() {
+			return m.matchFlag(string(c), strings.TrimPrefix(rest, "="))
+		}
+
+		// -f=something is strange
+		if strings.HasPrefix(rest, "=") {
+			return m.matchFlag(string(c), rest[1:])
+		}
+
+		if err := m.matchFlag(string(c), ""); err != nil {
+			return err
 		}
 	}
 
+	return nil
+}
+
+// Match the option with the given name and the value given with `=`, if any
+func (m *matcher) matchFlag(arg string, value string) error {
 	option := m.flags.option(arg)
 
 	if option == nil {
-		return m.fail("The `--%s` option does not exist.", arg)
+		return m.unknownOption(arg)
 	}
 
 	if value != "" && !option.acceptValue() {
-		return m.fail("The `--%s` option does not accept a value!", arg)
+		return m.fail(&InvalidValueError{Flag: option, Token: m.current(), Position: m.position(m.cursor), Value: value, Expected: ExpectNoValue})
+	}
+
+	// The last one of --color and --no-color wins
+	if option.isNegatable() {
+		m.options[option.name] = &Result{strconv.FormatBool(!option.hasNegatedName(arg))}
+		return nil
 	}
 
 	if value == "" && option.acceptValue() && m.hasNext() {
 		peek, err := m.peek()
-		if err == nil && len(peek) > 0 && peek[0] != '-' {
+		if err == nil && m.acceptsValue(option, peek) {
 			value = peek
 			m.next()
 		}
@@ -195,7 +430,7 @@ func (m *matcher) matchOption(arg string) error {
 
 	if value == "" {
 		if option.isRequired() {
-			return m.fail("The `--%s` option requires a value!", arg)
+			return m.fail(&MissingValueError{Flag: option, Token: m.current(), Position: m.position(m.cursor)})
 		}
 
 		if !option.isArray() && option.isOptional() {
@@ -203,34 +438,130 @@ func (m *matcher) matchOption(arg string) error {
 		}
 	}
 
+	// Aliases are stored under the canonical name of the option
+	name := option.name
+
 	if value != "" {
-		if _, ok := m.options[arg]; !ok {
-			m.setOption(arg, value)
+		if err := m.checkValue(option, value); err != nil {
+			return err
+		}
+		if _, ok := m.options[name]; !ok {
+			m.setOption(name, value)
 			return nil
 		}
 		if !option.isArray() {
-			return m.fail("The `--%s` option does not accept an array of values!", arg)
+			return m.fail(&InvalidValueError{Flag: option, Token: m.current(), Position: m.position(m.cursor), Value: value, Expected: ExpectSingleValue})
 		}
 		// Append to option
-		m.setOption(arg, value)
+		m.setOption(name, value)
+	} else if option.isCounter() {
+		m.setOption(name, "1")
 	} else {
-		m.setOption(arg)
+		m.setOption(name)
 	}
 
 	return nil
 }
 
+// Build the error for an option that is not in the signature
+func (m *matcher) unknownOption(name string) error {
+	return m.fail(&UnknownOptionError{
+		Name:        name,
+		Token:       m.current(),
+		Position:    m.position(m.cursor),
+		Suggestions: m.suggestOptions(name),
+	})
+}
+
+// Find the options with names close to the unknown one, i.e `--verbose` for `--verbos`.
+// Long names are only compared with long names and short names with short names
+func (m *matcher) suggestOptions(name string) []string {
+	names := []string{}
+	for _, flag := range m.flags {
+		if flag.isArgument() {
+			continue
+		}
+		for _, candidate := range flag.names() {
+			if (len(candidate) == 1) == (len(name) == 1) {
+				names = append(names, candidate)
+			}
+		}
+	}
+
+	suggestions := suggest(name, names, m.suggestionThreshold)
+	for i, suggestion := range suggestions {
+		if len(suggestion) == 1 {
+			suggestions[i] = "-" + suggestion
+		} else {
+			suggestions[i] = "--" + suggestion
+		}
+	}
+	return suggestions
+}
+
+// Check if the next arg can be used as the value of the option. Options that
+// require a value accept anything, even values starting with a dash, while
+// the others also accept negative numbers, i.e `--offset -5`
+func (m *matcher) acceptsValue(option *Flag, value string) bool {
+	switch {
+	case value == "":
+		return false
+	case value[0] != '-':
+		return true
+	case option.isRequired():
+		return true
+	}
+	return m.isNegativeNumber(value)
+}
+
+// Check if the arg is a negative number, i.e `-5` or `-3.2`. When the signature
+// has options that look like numbers, i.e {-1}, the arg is treated as an option
+func (m *matcher) isNegativeNumber(arg string) bool {
+	if !negativeNumber.MatchString(arg) {
+		return false
+	}
+
+	for _, flag := range m.flags {
+		if flag.isArgument() {
+			continue
+		}
+		for _, name := range flag.names() {
+			if negativeNumber.MatchString("-" + name) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Check if the signature has room for one more argument
+func (m *matcher) acceptsArgument() bool {
+	current := len(m.arguments)
+
+	if m.flags.argument(current) != nil {
+		return true
+	}
+	arg := m.flags.argument(current - 1)
+	return arg != nil && arg.isArray()
+}
+
 // Parse strings that are not starting with - as arguments and group them according to the signature
 func (m *matcher) matchArgument(argName string) error {
 	current := len(m.arguments)
 
-	if arg := m.flags.argument(current); arg != nil {
-		m.setArgument(arg.name, argName)
-	} else if arg := m.flags.argument(current - 1); arg != nil && arg.isArray() {
-		m.setArgument(arg.name, argName)
-	} else {
-		return m.fail("To many arguments!")
+	arg := m.flags.argument(current)
+	if arg == nil {
+		arg = m.flags.argument(current - 1)
+		if arg == nil || !arg.isArray() {
+			return m.fail(&TooManyArgumentsError{Flag: arg, Token: m.current(), Position: m.position(m.cursor)})
+		}
+	}
+
+	if err := m.checkValue(arg, argName); err != nil {
+		return err
 	}
+	m.setArgument(arg.name, argName)
 
 	return nil
 }
@@ -239,10 +570,14 @@ func (m *matcher) reset() {
 	m.cursor = 0
 	m.arguments = map[string]*Result{}
 	m.options = map[string]*Result{}
+	m.argumentSources = map[string]Source{}
+	m.optionSources = map[string]Source{}
+	m.passthrough = nil
+	m.terminated = false
 }
 
 // Clean the context and return the error
-func (m *matcher) fail(msg string, args ...interface{}) error {
+func (m *matcher) fail(err error) error {
 	m.reset()
-	return fmt.Errorf(msg, args...)
+	return err
 }
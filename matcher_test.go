@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
@@ -611,6 +612,585 @@ func TestMatchLongOption(t *testing.T) {
 	test(t, tests)
 }
 
+func TestMatchOptionAlias(t *testing.T) {
+	tests := []Test{
+		Test{
+			name:      "Match option by short alias",
+			flags:     flags("{-q|queue=}"),
+			args:      args("-q", "redis"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"queue": &Result{"redis"},
+			},
+		},
+		Test{
+			name:      "Match option by canonical name",
+			flags:     flags("{-q|queue=}"),
+			args:      args("--queue=redis"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"queue": &Result{"redis"},
+			},
+		},
+		Test{
+			name:      "Match option by canonical name with separate value",
+			flags:     flags("{-q|queue=}"),
+			args:      args("--queue", "redis"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"queue": &Result{"redis"},
+			},
+		},
+		Test{
+			name:      "Match array option by mixed aliases",
+			flags:     flags("{-q|queue=*}"),
+			args:      args("-q", "redis", "--queue=sqs"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"queue": &Result{"redis", "sqs"},
+			},
+		},
+		Test{
+			name:      "Match attached short option value",
+			flags:     flags("{-o|output=} {-n:int=}"),
+			args:      args("-ofile.txt", "-n5"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"output": &Result{"file.txt"},
+				"n":      &Result{"5"},
+			},
+		},
+		Test{
+			name:      "Match attached negative short option value",
+			flags:     flags("{-n:int=}"),
+			args:      args("-n-5"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"n": &Result{"-5"},
+			},
+		},
+		Test{
+			name:      "Match cluster with a trailing value",
+			flags:     flags("{-v} {-x} {-f=} {archive?}"),
+			args:      args("-vxf", "archive.tar"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"v": &Result{},
+				"x": &Result{},
+				"f": &Result{"archive.tar"},
+			},
+		},
+		Test{
+			name:  "Match cluster with an attached value",
+			flags: flags("{-v} {-f=} {archive?}"),
+			args:  args("-vfarchive.tar", "file"),
+			fail:  false,
+			arguments: map[string]*Result{
+				"archive": &Result{"file"},
+			},
+			options: map[string]*Result{
+				"v": &Result{},
+				"f": &Result{"archive.tar"},
+			},
+		},
+		Test{
+			name:      "Match cluster with a trailing value after `=`",
+			flags:     flags("{-a} {-b} {-c=}"),
+			args:      args("-abc=x"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"a": &Result{},
+				"b": &Result{},
+				"c": &Result{"x"},
+			},
+		},
+		Test{
+			name:  "Match a lone dash as an argument",
+			flags: flags("{--pattern=+} {file?}"),
+			args:  args("--pattern", "a", "-"),
+			fail:  false,
+			arguments: map[string]*Result{
+				"file": &Result{"-"},
+			},
+			options: map[string]*Result{
+				"pattern": &Result{"a"},
+			},
+		},
+		Test{
+			name:      "Match a lone dash without room for it (FAIL)",
+			flags:     flags("{-v}"),
+			args:      args("-v", "-"),
+			fail:      true,
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+		Test{
+			name:      "Match cluster with a required value at the end (FAIL)",
+			flags:     flags("{-v} {-f=+}"),
+			args:      args("-vf"),
+			fail:      true,
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+		Test{
+			name:      "Match long option value with `=`",
+			flags:     flags("{--define=}"),
+			args:      args("--define=key=value"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"define": &Result{"key=value"},
+			},
+		},
+		Test{
+			name:      "Match counter option",
+			flags:     flags("{-v|verbose+} {-f}"),
+			args:      args("-vvfv", "--verbose"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"verbose": &Result{"1", "1", "1", "1"},
+				"f":       &Result{},
+			},
+		},
+		Test{
+			name:      "Match counter option with value (FAIL)",
+			flags:     flags("{-v|verbose+}"),
+			args:      args("--verbose=2"),
+			fail:      true,
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+		Test{
+			name:      "Match negatable option",
+			flags:     flags("{-c|color!} {--cache!} {--tty!}"),
+			args:      args("--no-color", "-c", "--no-cache"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"color": &Result{"true"},
+				"cache": &Result{"false"},
+			},
+		},
+		Test{
+			name:      "Match negatable option with the last one winning",
+			flags:     flags("{--color!}"),
+			args:      args("--color", "--no-color"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"color": &Result{"false"},
+			},
+		},
+		Test{
+			name:      "Match negated option with value (FAIL)",
+			flags:     flags("{--color!}"),
+			args:      args("--no-color=yes"),
+			fail:      true,
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+		Test{
+			name:      "Match merged short aliases",
+			flags:     flags("{-f|force} {-q|quiet}"),
+			args:      args("-fq"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"force": &Result{},
+				"quiet": &Result{},
+			},
+		},
+	}
+
+	test(t, tests)
+}
+
+func TestTypedValues(t *testing.T) {
+	tests := []struct {
+		flags FlagList
+		args  []string
+		err   string
+	}{
+		{flags("{port:int}"), args("8080"), ""},
+		{flags("{port:int}"), args("http"), "The `port` argument expects an integer."},
+		{flags("{--port:int=}"), args("--port=80.5"), "The `--port` option expects an integer."},
+		{flags("{--port:int}"), args("--port", "80"), ""},
+		{flags("{--port:int}"), args("--port", "http"), "The `--port` option expects an integer."},
+		{flags("{--ratio:float=}"), args("--ratio", "0.5"), ""},
+		{flags("{--debug:bool=}"), args("--debug=yes"), "The `--debug` option expects a boolean."},
+		{flags("{--timeout:duration=5s}"), args(), ""},
+		{flags("{--timeout:duration=}"), args("--timeout=5"), "The `--timeout` option expects a duration."},
+		{flags("{endpoint:url}"), args("https://example.com/api"), ""},
+		{flags("{endpoint:url}"), args("example.com"), "The `endpoint` argument expects a URL."},
+		{flags("{-H|host:ip=*}"), args("-H", "::1", "-H", "10.0.0.256"), "The `--host` option expects an IP address."},
+		{flags("{dir:path}"), args("/tmp"), ""},
+		{flags("{files:file*}"), args("matcher.go", "missing.go"), "The `files` argument expects an existing file."},
+		{flags("{files:file*}"), args("matcher.go", "result.go"), ""},
+		{flags("{--format=json|yaml|table}"), args("--format=yaml"), ""},
+		{flags("{--format=json|yaml|table}"), args("--format", "xml"), "The `--format` option expects one of: json, yaml, table."},
+		{flags("{driver:mysql|pgsql}"), args("sqlite"), "The `driver` argument expects one of: mysql, pgsql."},
+		{flags("{--format:json|yaml}"), args("--format=xml"), "The `--format` option expects one of: json, yaml."},
+		{flags("{--format:json|yaml}"), args("--format", "yaml"), ""},
+		{flags("{--level:int=1|2|3}"), args("--level=4"), "The `--level` option expects one of: 1, 2, 3."},
+		{flags("{-l|label:map}"), args("-l", "env=prod", "--label=tier=web", "-lempty="), ""},
+		{flags("{-l|label:map}"), args("--label", "prod"), "The `--label` option expects a key=value pair."},
+		{flags("{-l|label:map}"), args("--label", "=prod"), "The `--label` option expects a key=value pair."},
+		{flags("{-l|label:map}"), args("-l", "env=prod", "-l", "env=dev"), "The `env` key is given more than once to the `--label` option!"},
+		{flags("{vars:map*}"), args("a=1", "b=2", "a=3"), "The `a` key is given more than once to the `vars` argument!"},
+	}
+
+	for i, test := range tests {
+		m := newMatcher(test.args, test.flags)
+		err := m.match()
+
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != test.err {
+			t.Errorf("Test #%d expected error `%s` but got `%s`!", i+1, test.err, msg)
+		}
+	}
+}
+
+func TestEnvValues(t *testing.T) {
+	env := map[string]string{
+		"INPUT_FILE": "env.txt",
+		"QUEUE_NAME": "sqs",
+		"PORT":       "http",
+		"FORCE":      "true",
+		"DRY_RUN":    "0",
+		"VERBOSITY":  "2",
+		"EMPTY":      "",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	tests := []struct {
+		flags     FlagList
+		args      []string
+		err       string
+		arguments map[string]*Result
+		options   map[string]*Result
+		sources   map[string]Source
+	}{
+		{
+			flags:     flags("{file @INPUT_FILE} {--queue=redis @QUEUE_NAME}"),
+			args:      args(),
+			arguments: map[string]*Result{"file": &Result{"env.txt"}},
+			options:   map[string]*Result{"queue": &Result{"sqs"}},
+			sources:   map[string]Source{"file": SourceEnv, "queue": SourceEnv},
+		},
+		{
+			flags:     flags("{file @INPUT_FILE} {--queue=redis @QUEUE_NAME}"),
+			args:      args("cli.txt", "--queue=beanstalkd"),
+			arguments: map[string]*Result{"file": &Result{"cli.txt"}},
+			options:   map[string]*Result{"queue": &Result{"beanstalkd"}},
+			sources:   map[string]Source{"file": SourceCommandLine, "queue": SourceCommandLine},
+		},
+		{
+			flags:     flags("{--queue=redis @EMPTY} {--driver=file @MISSING}"),
+			args:      args(),
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{"queue": &Result{"redis"}, "driver": &Result{"file"}},
+			sources:   map[string]Source{"queue": SourceDefault, "driver": SourceDefault},
+		},
+		{
+			flags:     flags("{-f|force @FORCE} {--dry-run @DRY_RUN}"),
+			args:      args(),
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{"force": &Result{}},
+			sources:   map[string]Source{"force": SourceEnv, "dry-run": SourceUnset},
+		},
+		{
+			flags:     flags("{--port:int= @PORT}"),
+			args:      args(),
+			err:       "The `PORT` environment variable of the `--port` option expects an integer.",
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+		{
+			flags:     flags("{-v|verbose+ @VERBOSITY}"),
+			args:      args(),
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{"verbose": &Result{"1", "1"}},
+			sources:   map[string]Source{"verbose": SourceEnv},
+		},
+		{
+			flags:     flags("{-v|verbose+ @QUEUE_NAME}"),
+			args:      args(),
+			err:       "The `QUEUE_NAME` environment variable of the `--verbose` option expects an integer.",
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+		{
+			flags:     flags("{--color! @DRY_RUN} {--tty! @FORCE}"),
+			args:      args(),
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{"color": &Result{"false"}, "tty": &Result{"true"}},
+			sources:   map[string]Source{"color": SourceEnv, "tty": SourceEnv},
+		},
+		{
+			flags:     flags("{--force @QUEUE_NAME}"),
+			args:      args(),
+			err:       "The `QUEUE_NAME` environment variable of the `--force` option expects a boolean.",
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+	}
+
+	for i, test := range tests {
+		m := newMatcher(test.args, test.flags)
+		m.lookupEnv = lookupEnv
+		err := m.match()
+
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != test.err {
+			t.Errorf("Test #%d expected error `%s` but got `%s`!", i+1, test.err, msg)
+		}
+
+		if !reflect.DeepEqual(test.arguments, m.arguments) || !reflect.DeepEqual(test.options, m.options) {
+			t.Errorf("Test #%d got arguments: %s, options: %s but expected: %s, %s!", i+1, m.arguments, m.options, test.arguments, test.options)
+		}
+
+		for name, source := range test.sources {
+			got := m.optionSources[name]
+			if _, ok := m.arguments[name]; ok {
+				got = m.argumentSources[name]
+			}
+			if got != source {
+				t.Errorf("Test #%d expected source `%s` for `%s` but got `%s`!", i+1, source, name, got)
+			}
+		}
+	}
+}
+
+func TestEndOfOptions(t *testing.T) {
+	tests := []Test{
+		Test{
+			name:  "Dash prefixed arguments after --",
+			flags: flags("{files*} {-f}"),
+			args:  args("-f", "--", "-rf", "--foo", "-"),
+			fail:  false,
+			arguments: map[string]*Result{
+				"files": &Result{"-rf", "--foo", "-"},
+			},
+			options: map[string]*Result{
+				"f": &Result{},
+			},
+		},
+		Test{
+			name:  "Arguments before and after --",
+			flags: flags("{a} {b} {--force}"),
+			args:  args("one", "--", "--force"),
+			fail:  false,
+			arguments: map[string]*Result{
+				"a": &Result{"one"},
+				"b": &Result{"--force"},
+			},
+			options: map[string]*Result{},
+		},
+		Test{
+			name:  "Second -- is an argument",
+			flags: flags("{a*}"),
+			args:  args("--", "x", "--"),
+			fail:  false,
+			arguments: map[string]*Result{
+				"a": &Result{"x", "--"},
+			},
+			options: map[string]*Result{},
+		},
+		Test{
+			name:      "Too many arguments before --",
+			flags:     flags("{a}"),
+			args:      args("one", "two", "--"),
+			fail:      true,
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+	}
+
+	test(t, tests)
+
+	m := newMatcher(args("--env", "prod", "--", "docker", "run", "-it", "alpine"), flags("{--env=}"))
+	if err := m.match(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(m.passthrough, args("docker", "run", "-it", "alpine")) {
+		t.Errorf("Expected passthrough args [docker run -it alpine] but got %v!", m.passthrough)
+	}
+
+	m = newMatcher(args("first", "--", "second", "third"), flags("{a} {b?}"))
+	if err := m.match(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(m.passthrough, args("third")) {
+		t.Errorf("Expected passthrough args [third] but got %v!", m.passthrough)
+	}
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	tests := []Test{
+		Test{
+			name:      "Negative value for numeric option",
+			flags:     flags("{--offset:int=}"),
+			args:      args("--offset", "-5"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"offset": &Result{"-5"},
+			},
+		},
+		Test{
+			name:      "Dash prefixed value for value-required option",
+			flags:     flags("{--exclude=+}"),
+			args:      args("--exclude", "-vendor", "--exclude", "--"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"exclude": &Result{"-vendor", "--"},
+			},
+		},
+		Test{
+			name:      "Negative value for untyped option",
+			flags:     flags("{--offset=} {n?}"),
+			args:      args("--offset", "-5"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"offset": &Result{"-5"},
+			},
+		},
+		Test{
+			name:      "Numeric options are not the value of an option",
+			flags:     flags("{--offset=} {-5}"),
+			args:      args("--offset", "-5"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"offset": &Result{},
+				"5":      &Result{},
+			},
+		},
+		Test{
+			name:  "Negative numbers as arguments",
+			flags: flags("{x:float} {y:int} {rest?*}"),
+			args:  args("-3.2", "-7", "-.5", "-1e3"),
+			fail:  false,
+			arguments: map[string]*Result{
+				"x":    &Result{"-3.2"},
+				"y":    &Result{"-7"},
+				"rest": &Result{"-.5", "-1e3"},
+			},
+			options: map[string]*Result{},
+		},
+		Test{
+			name:      "Numeric options disable negative number arguments",
+			flags:     flags("{n?} {-1}"),
+			args:      args("-1"),
+			fail:      false,
+			arguments: map[string]*Result{},
+			options: map[string]*Result{
+				"1": &Result{},
+			},
+		},
+		Test{
+			name:      "Dash prefixed words are still options",
+			flags:     flags("{n?}"),
+			args:      args("-5a"),
+			fail:      true,
+			arguments: map[string]*Result{},
+			options:   map[string]*Result{},
+		},
+	}
+
+	test(t, tests)
+}
+
+func TestMatcherErrors(t *testing.T) {
+	m := newMatcher(args("--verbos", "file"), flags("{file} {--verbose}"))
+	m.suggestionThreshold = 2
+	err := m.match()
+	var unknown *UnknownOptionError
+	if !errors.As(err, &unknown) || unknown.Name != "verbos" || unknown.Token != "--verbos" || unknown.Position != 0 || !reflect.DeepEqual(unknown.Suggestions, []string{"--verbose"}) {
+		t.Errorf("Expected unknown option error for `--verbos` at 0 but got %#v!", err)
+	}
+
+	m = newMatcher(args("-fx"), flags("{-f}"))
+	err = m.match()
+	if !errors.As(err, &unknown) || unknown.Name != "x" || unknown.Token != "-fx" {
+		t.Errorf("Expected unknown option error for `x` in `-fx` but got %#v!", err)
+	}
+
+	m = newMatcher(args("a"), flags("{a} {b} {c}"))
+	err = m.match()
+	var missing *MissingArgumentError
+	if !errors.As(err, &missing) || missing.Flag.name != "b" || len(missing.Missing) != 2 || missing.Position != -1 {
+		t.Errorf("Expected missing argument error for `b` and `c` but got %#v!", err)
+	}
+
+	// The default value of an argument doesn't stand for a missing one
+	m = newMatcher(args(), flags("{name} {other=foo}"))
+	err = m.match()
+	if !errors.As(err, &missing) || missing.Flag.name != "name" || len(missing.Missing) != 1 {
+		t.Errorf("Expected missing argument error for `name` but got %#v!", err)
+	}
+
+	m = newMatcher(args("a", "b"), flags("{a}"))
+	m.positions = []int{2, 3}
+	err = m.match()
+	var tooMany *TooManyArgumentsError
+	if !errors.As(err, &tooMany) || tooMany.Flag.name != "a" || tooMany.Token != "b" || tooMany.Position != 3 {
+		t.Errorf("Expected too many arguments error for `b` at 3 but got %#v!", err)
+	}
+
+	m = newMatcher(args("x", "--file"), flags("{x} {--file=+}"))
+	err = m.match()
+	var missingValue *MissingValueError
+	if !errors.As(err, &missingValue) || missingValue.Flag.name != "file" || missingValue.Token != "--file" || missingValue.Position != 1 {
+		t.Errorf("Expected missing value error for `--file` at 1 but got %#v!", err)
+	}
+
+	tests := []struct {
+		args     []string
+		flags    FlagList
+		token    string
+		position int
+		value    string
+		expected string
+	}{
+		{args("--port", "http"), flags("{--port:int=}"), "http", 1, "http", "an integer"},
+		{args("--format=xml"), flags("{--format=json|yaml}"), "--format=xml", 0, "xml", "one of: json, yaml"},
+		{args("x", "-f=yes"), flags("{x} {-f}"), "-f=yes", 1, "yes", ExpectNoValue},
+		{args("-q", "a", "-q", "b"), flags("{-q|queue=}"), "b", 3, "b", ExpectSingleValue},
+		{args("80", "eighty"), flags("{ports:int*}"), "eighty", 1, "eighty", "an integer"},
+	}
+
+	for i, test := range tests {
+		m := newMatcher(test.args, test.flags)
+		err := m.match()
+
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) || invalid.Token != test.token || invalid.Position != test.position || invalid.Value != test.value || invalid.Expected != test.expected {
+			t.Errorf("Test #%d expected invalid value error for `%s` at %d but got %#v!", i+1, test.token, test.position, err)
+		}
+	}
+}
+
 func TestCombined(t *testing.T) {
 
 	tests := []Test{
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// How many times Confirm, Choice and MultiChoice ask again after an invalid answer
+const DefaultAttempts = 3
+
+// Validates an answer. The error is displayed before asking again
+type Validator func(answer string) error
+
+// Display a message and wait for an answer until the validator accepts it.
+// After `attempts` invalid answers the last error is returned, while 0 asks until a valid answer
+func (ctx *Context) AskWithValidation(msg string, attempts int, validate Validator) (string, error) {
+	for i := 0; attempts <= 0 || i < attempts; i++ {
+		fmt.Fprint(ctx.Writer, msg)
+
+		answer, err := ctx.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		if err = validate(answer); err == nil {
+			return answer, nil
+		}
+		fmt.Fprintln(ctx.Writer, err.Error())
+
+		if i == attempts-1 {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// Ask a yes/no question, i.e `Continue? [Y/n] `. An empty answer picks the default
+func (ctx *Context) Confirm(msg string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+
+	confirmed := def
+	_, err := ctx.AskWithValidation(fmt.Sprintf("%s %s ", msg, hint), DefaultAttempts, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "":
+			confirmed = def
+		case "y", "yes":
+			confirmed = true
+		case "n", "no":
+			confirmed = false
+		default:
+			return errors.New("Please answer with yes or no.")
+		}
+		return nil
+	})
+
+	return confirmed, err
+}
+
+// Ask to pick one of the options by its number or by its value.
+// An empty answer picks the default, if there is one
+func (ctx *Context) Choice(msg string, options []string, def string) (string, error) {
+	choice := ""
+	_, err := ctx.AskWithValidation(choiceQuestion(msg, options, def), DefaultAttempts, func(answer string) error {
+		if answer == "" && def != "" {
+			answer = def
+		}
+
+		var err error
+		choice, err = findChoice(options, answer)
+		return err
+	})
+
+	return choice, err
+}
+
+// Ask to pick some of the options by their numbers or values separated by commas, i.e `1, 3`.
+// An empty answer picks the defaults, if there are any
+func (ctx *Context) MultiChoice(msg string, options []string, defaults []string) ([]string, error) {
+	question := choiceQuestion(msg+" (separated by commas)", options, strings.Join(defaults, ", "))
+
+	choices := []string{}
+	_, err := ctx.AskWithValidation(question, DefaultAttempts, func(answer string) error {
+		answers := strings.Split(answer, ",")
+		if strings.TrimSpace(answer) == "" {
+			if len(defaults) == 0 {
+				return errors.New("Please pick at least one option.")
+			}
+			answers = defaults
+		}
+
+		choices = []string{}
+		for _, answer := range answers {
+			choice, err := findChoice(options, strings.TrimSpace(answer))
+			if err != nil {
+				return err
+			}
+			choices = append(choices, choice)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return choices, nil
+}
+
+// Build the question with the numbered options, i.e:
+//
+//	Pick a driver [mysql]:
+//	  [1] mysql
+//	  [2] pgsql
+//	>
+func choiceQuestion(msg string, options []string, def string) string {
+	question := msg
+	if def != "" {
+		question += " [" + def + "]"
+	}
+	question += ":\n"
+
+	for i, option := range options {
+		question += fmt.Sprintf("  [%d] %s\n", i+1, option)
+	}
+
+	return question + "> "
+}
+
+// Find the option by its number or by its value
+func findChoice(options []string, answer string) (string, error) {
+	for _, option := range options {
+		if strings.EqualFold(option, answer) {
+			return option, nil
+		}
+	}
+
+	if i, err := strconv.Atoi(answer); err == nil && i > 0 && i <= len(options) {
+		return options[i-1], nil
+	}
+
+	return "", fmt.Errorf("`%s` is not a valid choice.", answer)
+}
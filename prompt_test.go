@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Creates a context that reads the given input
+func promptContext(input string) (*Context, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return newContext(strings.NewReader(input), out, map[string]*Result{}, map[string]*Result{}), out
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		def      bool
+		expected bool
+		fail     bool
+	}{
+		{"y\n", false, true, false},
+		{"YES\n", false, true, false},
+		{"no\n", true, false, false},
+		{"\n", true, true, false},
+		{"\n", false, false, false},
+		{"maybe\nsure\nn\n", true, false, false},
+		{"maybe\nsure\nnope\n", true, false, true},
+		{"", true, false, true},
+	}
+
+	for i, test := range tests {
+		ctx, _ := promptContext(test.input)
+		confirmed, err := ctx.Confirm("Continue?", test.def)
+
+		if (err != nil) != test.fail {
+			t.Errorf("Test #%d expected fail to be `%t` but got error `%v`!", i+1, test.fail, err)
+		}
+		if !test.fail && confirmed != test.expected {
+			t.Errorf("Test #%d expected `%t` but got `%t`!", i+1, test.expected, confirmed)
+		}
+	}
+
+	ctx, out := promptContext("x\ny\n")
+	ctx.Confirm("Continue?", true)
+	if expected := "Continue? [Y/n] Please answer with yes or no.\nContinue? [Y/n] "; out.String() != expected {
+		t.Errorf("Expected output `%s` but got `%s`!", expected, out.String())
+	}
+}
+
+func TestChoice(t *testing.T) {
+	options := []string{"mysql", "pgsql", "sqlite"}
+
+	tests := []struct {
+		input    string
+		def      string
+		expected string
+		fail     bool
+	}{
+		{"2\n", "", "pgsql", false},
+		{"SQLite\n", "", "sqlite", false},
+		{"\n", "mysql", "mysql", false},
+		{"\n4\n0\n", "", "", true},
+		{"oracle\n1\n", "", "mysql", false},
+	}
+
+	for i, test := range tests {
+		ctx, _ := promptContext(test.input)
+		choice, err := ctx.Choice("Pick a driver", options, test.def)
+
+		if (err != nil) != test.fail {
+			t.Errorf("Test #%d expected fail to be `%t` but got error `%v`!", i+1, test.fail, err)
+		}
+		if choice != test.expected {
+			t.Errorf("Test #%d expected `%s` but got `%s`!", i+1, test.expected, choice)
+		}
+	}
+
+	ctx, out := promptContext("1\n")
+	ctx.Choice("Pick a driver", options, "pgsql")
+	if expected := "Pick a driver [pgsql]:\n  [1] mysql\n  [2] pgsql\n  [3] sqlite\n> "; out.String() != expected {
+		t.Errorf("Expected output `%s` but got `%s`!", expected, out.String())
+	}
+}
+
+func TestMultiChoice(t *testing.T) {
+	options := []string{"mysql", "pgsql", "sqlite"}
+
+	tests := []struct {
+		input    string
+		defaults []string
+		expected []string
+		fail     bool
+	}{
+		{"1, 3\n", nil, []string{"mysql", "sqlite"}, false},
+		{"pgsql,2\n", nil, []string{"pgsql", "pgsql"}, false},
+		{"\n", []string{"sqlite"}, []string{"sqlite"}, false},
+		{"\n1,x\n2\n", nil, []string{"pgsql"}, false},
+		{"\n\n\n", nil, nil, true},
+	}
+
+	for i, test := range tests {
+		ctx, _ := promptContext(test.input)
+		choices, err := ctx.MultiChoice("Pick the drivers", options, test.defaults)
+
+		if (err != nil) != test.fail {
+			t.Errorf("Test #%d expected fail to be `%t` but got error `%v`!", i+1, test.fail, err)
+		}
+		if !reflect.DeepEqual(choices, test.expected) {
+			t.Errorf("Test #%d expected %v but got %v!", i+1, test.expected, choices)
+		}
+	}
+}
+
+func TestAskWithValidation(t *testing.T) {
+	notEmpty := func(answer string) error {
+		if answer == "" {
+			return errors.New("The name cannot be empty.")
+		}
+		return nil
+	}
+
+	ctx, out := promptContext("\n  \nJohnny \n")
+	name, err := ctx.AskWithValidation("Name: ", 3, notEmpty)
+	if err != nil || name != "Johnny" {
+		t.Errorf("Expected `Johnny` but got `%s` (%v)!", name, err)
+	}
+	if expected := "Name: The name cannot be empty.\nName: The name cannot be empty.\nName: "; out.String() != expected {
+		t.Errorf("Expected output `%s` but got `%s`!", expected, out.String())
+	}
+
+	ctx, _ = promptContext("\n\nJohnny\n")
+	if _, err := ctx.AskWithValidation("Name: ", 2, notEmpty); err == nil || err.Error() != "The name cannot be empty." {
+		t.Errorf("Expected the validation error after the last attempt but got `%v`!", err)
+	}
+
+	ctx, _ = promptContext("\n\n\n\nJohnny")
+	if name, err := ctx.AskWithValidation("Name: ", 0, notEmpty); err != nil || name != "Johnny" {
+		t.Errorf("Expected `Johnny` without a limit of attempts but got `%s` (%v)!", name, err)
+	}
+
+	ctx, _ = promptContext("\n")
+	if _, err := ctx.AskWithValidation("Name: ", 0, notEmpty); err != io.EOF {
+		t.Errorf("Expected EOF error but got `%v`!", err)
+	}
+}
+
+func TestPromptsShareTheInput(t *testing.T) {
+	ctx, _ := promptContext("y\n2\nJohnny\n")
+
+	confirmed, _ := ctx.Confirm("Continue?", false)
+	choice, _ := ctx.Choice("Pick a driver", []string{"mysql", "pgsql"}, "")
+	name, _ := ctx.AskWithValidation("Name: ", 1, func(string) error { return nil })
+
+	if !confirmed || choice != "pgsql" || name != "Johnny" {
+		t.Errorf("Expected every prompt to get its answer but got `%t`, `%s`, `%s`!", confirmed, choice, name)
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Replace the `@path` args with the args read from the files, i.e `app build @args.txt`.
+// Files can reference other files, `@@literal` is kept as `@literal` and
+// the args after `--` are never expanded
+func expandResponseFiles(args []string) ([]string, error) {
+	expanded, _, err := expandArgs(args, nil)
+	return expanded, err
+}
+
+// Expand the args of the command line or of a response file. The files that are
+// being expanded are used to detect the cycles. It also tells if `--` was found
+func expandArgs(args []string, parents []string) ([]string, bool, error) {
+	expanded := []string{}
+
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return append(expanded, args[i:]...), true, nil
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+		case strings.HasPrefix(arg, "@") && len(arg) > 1:
+			nested, terminated, err := expandFile(arg[1:], parents)
+			if err != nil {
+				return nil, false, err
+			}
+			expanded = append(expanded, nested...)
+			if terminated {
+				return append(expanded, args[i+1:]...), true, nil
+			}
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+
+	return expanded, false, nil
+}
+
+// Read the args of a response file and expand the files it references
+func expandFile(path string, parents []string) ([]string, bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("The `%s` response file can't be read: %w", path, err)
+	}
+	for _, parent := range parents {
+		if parent == abs {
+			return nil, false, fmt.Errorf("The `%s` response file includes itself!", path)
+		}
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("The `%s` response file can't be read: %w", path, err)
+	}
+
+	args, err := splitArgs(string(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("The `%s` response file is invalid: %w", path, err)
+	}
+
+	return expandArgs(args, append(parents, abs))
+}
+
+// Split the content of a response file into args with shell-like rules: args are
+// separated by whitespace, single quotes keep everything, double quotes allow the
+// `\"` and `\\` escapes, a backslash escapes any character outside of the quotes
+// and `#` at the start of an arg comments the rest of the line
+func splitArgs(content string) ([]string, error) {
+	args := []string{}
+	arg := strings.Builder{}
+	inArg := false
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '#' && !inArg:
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '\\':
+			if i+1 < len(content) {
+				i++
+				arg.WriteByte(content[i])
+			}
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(content[i+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("the `'` is not closed")
+			}
+			arg.WriteString(content[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			closed := false
+			for i++; i < len(content); i++ {
+				if content[i] == '"' {
+					closed = true
+					break
+				}
+				if content[i] == '\\' && i+1 < len(content) && (content[i+1] == '"' || content[i+1] == '\\') {
+					i++
+				}
+				arg.WriteByte(content[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("the `\"` is not closed")
+			}
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
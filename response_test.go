@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+		fail     bool
+	}{
+		{"", []string{}, false},
+		{"  build\tmain.go\n--output=out \r\n", []string{"build", "main.go", "--output=out"}, false},
+		{`"my file.txt" 'single "quoted"' mixed"quo"'tes'`, []string{"my file.txt", `single "quoted"`, "mixedquotes"}, false},
+		{`"escaped \" and \\ but not \n" 'no \escapes'`, []string{`escaped " and \ but not \n`, `no \escapes`}, false},
+		{`a\ b \'c\'`, []string{"a b", "'c'"}, false},
+		{"\"\" ''", []string{"", ""}, false},
+		{"# comment\n-v # another one\nfile#1", []string{"-v", "file#1"}, false},
+		{`"not closed`, nil, true},
+		{`'not closed`, nil, true},
+	}
+
+	for i, test := range tests {
+		args, err := splitArgs(test.content)
+		if (err != nil) != test.fail {
+			t.Errorf("Test #%d expected fail to be `%t` but got error `%v`!", i+1, test.fail, err)
+			continue
+		}
+		if !test.fail && !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("Test #%d expected args %q but got %q!", i+1, test.expected, args)
+		}
+	}
+}
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	files := map[string]string{
+		"args.txt":       "--env=prod 'my file.txt' # comment\n-v @'" + path("nested.txt") + "'",
+		"nested.txt":     "--tag a\\ b @@literal",
+		"terminated.txt": "--force -- @args.txt",
+		"cycle.txt":      "-v @" + path("loop.txt"),
+		"loop.txt":       "@" + path("cycle.txt"),
+		"invalid.txt":    "'not closed",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(path(name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args     []string
+		expected []string
+		fail     bool
+	}{
+		{args("build", "@"+path("args.txt"), "main.go"), args("build", "--env=prod", "my file.txt", "-v", "--tag", "a b", "@literal", "main.go"), false},
+		{args("@@user", "@"), args("@user", "@"), false},
+		{args("exec", "--", "@"+path("args.txt")), args("exec", "--", "@"+path("args.txt")), false},
+		{args("@"+path("terminated.txt"), "@"+path("args.txt")), args("--force", "--", "@args.txt", "@"+path("args.txt")), false},
+		{args("@" + path("cycle.txt")), nil, true},
+		{args("@" + path("missing.txt")), nil, true},
+		{args("@" + path("invalid.txt")), nil, true},
+	}
+
+	for i, test := range tests {
+		expanded, err := expandResponseFiles(test.args)
+		if (err != nil) != test.fail {
+			t.Errorf("Test #%d expected fail to be `%t` but got error `%v`!", i+1, test.fail, err)
+			continue
+		}
+		if !test.fail && !reflect.DeepEqual(expanded, test.expected) {
+			t.Errorf("Test #%d expected args %q but got %q!", i+1, test.expected, expanded)
+		}
+	}
+
+	var options map[string]*Result
+	app, _ := testApp(&Command{
+		Name:      "build",
+		Signature: "{--env=} {--tag=*} {args*}",
+		Action: func(ctx *Context) {
+			options = ctx.Options
+		},
+	})
+
+	app.RunE(args("app", "build", "@"+path("args.txt")))
+	if _, ok := options["env"]; ok {
+		t.Errorf("Response files should be expanded only when they are enabled!")
+	}
+
+	app.ResponseFiles = true
+	if err := app.RunE(args("app", "build", "@"+path("args.txt"))); err != nil {
+		t.Errorf("Run failed with error: %s", err)
+	}
+	if !reflect.DeepEqual(options["tag"], &Result{"a b"}) || !reflect.DeepEqual(options["env"], &Result{"prod"}) {
+		t.Errorf("Expected the options from the response file but got %v!", options)
+	}
+
+	if err := app.RunE(args("app", "build", "@"+path("cycle.txt"))); ExitCode(err) != 2 {
+		t.Errorf("Expected an usage error for a cycle but got %v!", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 )
 
 // Every option or argument will have a result object that will contains all the matched data
@@ -15,6 +16,65 @@ func (r *Result) Append(item ...string) {
 	*r = append(*r, item...)
 }
 
+// Convert the key=value items into a map, i.e --label env=prod --label tier=web
+func (r Result) Map() (map[string]string, error) {
+	items := make(map[string]string, len(r))
+
+	for _, item := range r {
+		key, value, err := splitPair(item)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := items[key]; ok {
+			return nil, fmt.Errorf("The `%s` key is given more than once", key)
+		}
+		items[key] = value
+	}
+
+	return items, nil
+}
+
+// The state of a negatable option, i.e {--color!}
+type Tristate int
+
+const (
+	TristateUnset Tristate = iota
+	TristateTrue
+	TristateFalse
+)
+
+func (s Tristate) String() string {
+	switch s {
+	case TristateTrue:
+		return "true"
+	case TristateFalse:
+		return "false"
+	}
+	return "unset"
+}
+
+// Get the state of a boolean option: true when it was given without a value or
+// with a true one, i.e --color, false for a false value, i.e --no-color
+func (r Result) State() Tristate {
+	if len(r) == 0 {
+		return TristateTrue
+	}
+
+	value, err := strconv.ParseBool(r[0])
+	switch {
+	case err != nil:
+		return TristateUnset
+	case value:
+		return TristateTrue
+	}
+	return TristateFalse
+}
+
+// Get the number of items, i.e how many times a counter option like {-v+} was given
+func (r Result) Count() int {
+	return len(r)
+}
+
 // Check if there is an item at position `i`
 func (r Result) Has(i int) bool {
 	if i < 0 || i > len(r)-1 {
@@ -45,6 +105,39 @@ func (r Result) Int(i ...int) (int, error) {
 	return strconv.Atoi(r[pos])
 }
 
+// Convert the first (or specified) item from string to float
+func (r Result) Float(i ...int) (float64, error) {
+	pos := getPos(i)
+
+	if !r.Has(pos) {
+		return -1, errors.New("Item not found!")
+	}
+
+	return strconv.ParseFloat(r[pos], 64)
+}
+
+// Convert the first (or specified) item from string to bool
+func (r Result) Bool(i ...int) (bool, error) {
+	pos := getPos(i)
+
+	if !r.Has(pos) {
+		return false, errors.New("Item not found!")
+	}
+
+	return strconv.ParseBool(r[pos])
+}
+
+// Convert the first (or specified) item from string to duration, i.e `1m30s`
+func (r Result) Duration(i ...int) (time.Duration, error) {
+	pos := getPos(i)
+
+	if !r.Has(pos) {
+		return 0, errors.New("Item not found!")
+	}
+
+	return time.ParseDuration(r[pos])
+}
+
 // Returns the content of the Result as string slice
 func (r Result) StrSlice() []string {
 	return r
@@ -1,6 +1,10 @@
 package cli
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+)
 
 func TestNewResult(t *testing.T) {
 	r := Result{}
@@ -96,3 +100,72 @@ func TestIntResult(t *testing.T) {
 		t.Errorf("Got no error but expected one. Item: `%d`!", item)
 	}
 }
+
+func TestCountResult(t *testing.T) {
+	if count := (Result{"1", "1", "1"}).Count(); count != 3 {
+		t.Errorf("r.Count() expected `3` but got `%d`!", count)
+	}
+
+	if count := (Result{}).Count(); count != 0 {
+		t.Errorf("r.Count() expected `0` but got `%d`!", count)
+	}
+}
+
+func TestStateResult(t *testing.T) {
+	tests := []struct {
+		result   Result
+		expected Tristate
+	}{
+		{Result{}, TristateTrue},
+		{Result{"true"}, TristateTrue},
+		{Result{"false"}, TristateFalse},
+		{Result{"0"}, TristateFalse},
+		{Result{"maybe"}, TristateUnset},
+	}
+
+	for i, test := range tests {
+		if state := test.result.State(); state != test.expected {
+			t.Errorf("Test #%d expected state `%s` but got `%s`!", i+1, test.expected, state)
+		}
+	}
+}
+
+func TestMapResult(t *testing.T) {
+	items, err := (Result{"env=prod", "tier=web", "url=a=b", "empty="}).Map()
+	expected := map[string]string{"env": "prod", "tier": "web", "url": "a=b", "empty": ""}
+	if err != nil || !reflect.DeepEqual(items, expected) {
+		t.Errorf("r.Map() expected `%v` but got `%v` (%v)!", expected, items, err)
+	}
+
+	if _, err := (Result{"env=prod", "env=dev"}).Map(); err == nil {
+		t.Error("Got no error but expected one for a duplicated key!")
+	}
+
+	if _, err := (Result{"env"}).Map(); err == nil {
+		t.Error("Got no error but expected one for a malformed pair!")
+	}
+}
+
+func TestTypedResult(t *testing.T) {
+	r := Result{"2.5", "true", "1m30s"}
+
+	if item, err := r.Float(); err != nil || item != 2.5 {
+		t.Errorf("r.Float() expected `%f` but got `%f`!", 2.5, item)
+	}
+
+	if item, err := r.Bool(1); err != nil || !item {
+		t.Errorf("r.Bool(1) expected `true` but got `%t`!", item)
+	}
+
+	if item, err := r.Duration(2); err != nil || item != 90*time.Second {
+		t.Errorf("r.Duration(2) expected `%s` but got `%s`!", 90*time.Second, item)
+	}
+
+	if _, err := r.Duration(0); err == nil {
+		t.Error("Got no error but expected duration parse error!")
+	}
+
+	if _, err := r.Float(3); err == nil {
+		t.Error("Got no error but expected one for out of bounds item!")
+	}
+}
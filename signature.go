@@ -1,47 +1,110 @@
 package cli
 
 import (
-	"regexp"
+	"fmt"
 	"strings"
 )
 
-func (cmd *Command) parse() {
-	re := regexp.MustCompile("{([^{}]*)}")
-	matches := re.FindAllStringSubmatch(cmd.Signature, -1)
+// Parse the signature into the flag list of the command.
+// The signature is parsed only once, unless it was changed in the meantime
+func (cmd *Command) parse() error {
+	if cmd.parsed && cmd.parsedSignature == cmd.Signature {
+		return cmd.parseErr
+	}
+
+	cmd.parsed = true
+	cmd.parsedSignature = cmd.Signature
+	cmd.Flags, cmd.parseErr = ParseSignature(cmd.Signature)
+	if cmd.parseErr != nil {
+		cmd.Flags = FlagList{}
+	}
+
+	return cmd.parseErr
+}
 
-	//
+// Parse a signature like "{file} {--output=}" into a flag list.
+// The returned error is a *SignatureError with the position of the problem
+func ParseSignature(signature string) (FlagList, error) {
+	flags := FlagList{}
 	hadArrayArg := false
 
-	for _, m := range matches {
-		var f *Flag
-		flag := m[1]
+	fail := func(pos int, msg string, args ...interface{}) (FlagList, error) {
+		return nil, &SignatureError{Signature: signature, Pos: pos, Msg: fmt.Sprintf(msg, args...)}
+	}
+
+	for i := 0; i < len(signature); i++ {
+		switch c := signature[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			continue
+		case c == '}':
+			return fail(i, "Unexpected `}` without a matching `{`")
+		case c != '{':
+			return fail(i, "Unexpected `%c` outside of braces", c)
+		}
 
-		if len(flag) == 0 {
-			panic("Flag cannot be empty! Syntax like {} is not acceptable!")
+		end := strings.IndexAny(signature[i+1:], "{}")
+		if end == -1 || signature[i+1+end] == '{' {
+			return fail(i, "The `{` is not closed")
 		}
+		end += i + 1
 
-		if flag[0] == '-' {
-			f = cmd.parseOption(flag)
+		content := signature[i+1 : end]
+		if strings.TrimSpace(content) == "" {
+			return fail(i, "Flag cannot be empty! Syntax like {} is not acceptable")
+		}
+
+		var flag *Flag
+		var err error
+
+		if content[0] == '-' {
+			flag, err = parseOption(content)
 		} else {
 			if hadArrayArg {
-				panic("After an array argument, command cannot have any other arguments!")
+				return fail(i, "After an array argument, command cannot have any other arguments")
 			}
+			flag, err = parseArgument(content)
+		}
 
-			f = cmd.parseArgument(flag)
+		if err != nil {
+			return fail(i, "%s", err.Error())
+		}
 
-			if f.isArray() {
-				hadArrayArg = true
+		names := flag.names()
+		for j, name := range names {
+			if name == "" {
+				return fail(i, "Flag `%s` has an empty name", content)
+			}
+			if strings.ContainsAny(name, " \t\r\n?*=!+") {
+				return fail(i, "Flag `%s` has an invalid name `%s`", content, name)
+			}
+			for _, previous := range names[:j] {
+				if previous == name {
+					return fail(i, "The `%s` name is used more than once by the `%s` flag", name, flag.name)
+				}
+			}
+			if existing := flags.find(name, flag.isArgument()); existing != nil {
+				return fail(i, "The `%s` name is already used by the `%s` flag", name, existing.name)
 			}
 		}
+
+		if flag.isArgument() && flag.isArray() {
+			hadArrayArg = true
+		}
+
+		flags = append(flags, flag)
+		i = end
 	}
+
+	return flags, nil
 }
 
-// Parses syntax like {--queue}, {-q} for options
-func (cmd *Command) parseOption(opt string) *Flag {
+// Parses syntax like {--queue}, {-q}, {-q|queue}, {--port:int}, {--port:int=}, {--format=json|yaml}
+// {--queue=redis @QUEUE_NAME}, {-v|verbose+} or {--color!} for options
+func parseOption(opt string) (*Flag, error) {
 	var description string
 	var implicitValue string
 	var kind int8
-	var options int8
+	var options int16
 
 	if strings.HasPrefix(opt, "--") {
 		kind = longOptionFlag
@@ -52,6 +115,7 @@ func (cmd *Command) parseOption(opt string) *Flag {
 	}
 
 	opt, description = extractDescription(opt)
+	opt, env := extractEnv(opt)
 
 	switch {
 	case strings.HasSuffix(opt, "="):
@@ -72,29 +136,70 @@ func (cmd *Command) parseOption(opt string) *Flag {
 		opt = parts[0]
 		options = valueOptional
 		break
+	case strings.HasSuffix(opt, "+"):
+		options = valueNone | counter
+		opt = strings.TrimSuffix(opt, "+")
+		break
+	case strings.HasSuffix(opt, "!"):
+		options = valueNone | negatable
+		opt = strings.TrimSuffix(opt, "!")
+		break
 	default:
 		options = valueNone
 	}
 
+	opt, valueType, choices, err := extractType(opt)
+	if err != nil {
+		return nil, err
+	}
+	name, aliases := extractAliases(opt)
+
+	if valueType != "" || len(choices) > 0 {
+		// Counters and negatable options never take a value, so their type would not be used
+		if options&(counter|negatable) != 0 {
+			return nil, fmt.Errorf("The `%s` option takes no value, so it cannot have a type or choices", name)
+		}
+		// A typed option without `=` requires a value, i.e {--port:int} or {--format:json|yaml}
+		if options&valueNone == valueNone {
+			options = valueRequired
+		}
+	}
+	if strings.Contains(implicitValue, "|") {
+		if implicitValue, choices, err = extractChoices(implicitValue); err != nil {
+			return nil, err
+		}
+	}
+	// Map options accumulate the key=value pairs, i.e {--label:map}
+	if valueType == "map" {
+		options = valueRequired | valueArray
+	}
+
 	flag := &Flag{
 		kind:        kind,
-		name:        opt,
+		name:        name,
+		aliases:     aliases,
 		description: description,
 		options:     options,
 		value:       implicitValue,
+		valueType:   valueType,
+		choices:     choices,
+		Env:         env,
+	}
+	if err := checkDefault(flag); err != nil {
+		return nil, err
 	}
-	cmd.Flags = append(cmd.Flags, flag)
 
-	return flag
+	return flag, nil
 }
 
-// Parses {argument} like syntax
-func (cmd *Command) parseArgument(arg string) *Flag {
+// Parses {argument}, {argument?}, {argument:int}, {argument:json|yaml} or {argument @ENV_NAME} like syntax
+func parseArgument(arg string) (*Flag, error) {
 	var implicitValue string
 	var description string
-	var options int8
+	var options int16
 
 	arg, description = extractDescription(arg)
+	arg, env := extractEnv(arg)
 
 	switch {
 	case strings.HasSuffix(arg, "?*"):
@@ -119,16 +224,31 @@ func (cmd *Command) parseArgument(arg string) *Flag {
 		options = required
 	}
 
+	arg, valueType, choices, err := extractType(arg)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(implicitValue, "|") {
+		if implicitValue, choices, err = extractChoices(implicitValue); err != nil {
+			return nil, err
+		}
+	}
+
 	flag := &Flag{
 		name:        arg,
 		kind:        argumentFlag,
 		options:     options,
 		description: description,
 		value:       implicitValue,
+		valueType:   valueType,
+		choices:     choices,
+		Env:         env,
+	}
+	if err := checkDefault(flag); err != nil {
+		return nil, err
 	}
-	cmd.Flags = append(cmd.Flags, flag)
 
-	return flag
+	return flag, nil
 }
 
 // Extract the name and the description from {something : Description} syntax
@@ -140,3 +260,74 @@ func extractDescription(n string) (string, string) {
 
 	return n, ""
 }
+
+// Extract the environment variable from {--queue=redis @QUEUE_NAME} syntax
+func extractEnv(n string) (string, string) {
+	pos := strings.LastIndex(n, " @")
+	if pos == -1 {
+		return n, ""
+	}
+
+	return strings.TrimSpace(n[:pos]), strings.TrimSpace(n[pos+2:])
+}
+
+// Extract the name and the type from {port:int} syntax. A type like
+// {format:json|yaml} is a list of choices
+func extractType(n string) (string, string, []string, error) {
+	pos := strings.Index(n, ":")
+	if pos == -1 {
+		return n, "", nil, nil
+	}
+
+	name, valueType := n[:pos], n[pos+1:]
+	if strings.Contains(valueType, "|") {
+		_, choices, err := extractChoices(valueType)
+		return name, "", choices, err
+	}
+	if _, ok := valueTypes[valueType]; !ok {
+		return "", "", nil, fmt.Errorf("Unknown type `%s` for `%s`", valueType, name)
+	}
+
+	return name, valueType, nil, nil
+}
+
+// Extract the choices from {--format=json|yaml} syntax. The first choice is the default value
+func extractChoices(value string) (string, []string, error) {
+	choices := strings.Split(value, "|")
+	for _, choice := range choices {
+		if choice == "" {
+			return "", nil, fmt.Errorf("The choices `%s` cannot be empty", value)
+		}
+	}
+	return choices[0], choices, nil
+}
+
+// Make sure the default value and the choices match the type of the flag
+func checkDefault(flag *Flag) error {
+	if flag.valueType == "" || valueTypes[flag.valueType].runtime {
+		return nil
+	}
+	for _, value := range append([]string{flag.value}, flag.choices...) {
+		if value != "" && flag.checkValue(value) != nil {
+			return fmt.Errorf("The default value and the choices of `%s` should be %s", flag.name, flag.expects())
+		}
+	}
+	return nil
+}
+
+// Extract the canonical name and the aliases from {-q|queue} syntax.
+// The last name is the canonical one, the ones before it are aliases
+func extractAliases(n string) (string, []string) {
+	names := strings.Split(n, "|")
+	last := len(names) - 1
+
+	for i := range names {
+		names[i] = strings.TrimLeft(names[i], "-")
+	}
+
+	if last == 0 {
+		return names[0], nil
+	}
+
+	return names[last], names[:last]
+}
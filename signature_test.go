@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -216,6 +217,221 @@ func TestOptionWithDefaultValue(t *testing.T) {
 	}
 }
 
+func TestOptionWithAliases(t *testing.T) {
+	flags := toFlags("{-q|queue=redis : The queue driver}")
+	if len(flags) < 1 {
+		t.Errorf("Expected 1 value flag but got `%d`!", len(flags))
+		return
+	}
+	if flags[0].name != "queue" || flags[0].value != "redis" || flags[0].description != "The queue driver" {
+		t.Errorf("Option should be named `queue` with default=redis but got: name=%s, val=%s", flags[0].name, flags[0].value)
+	}
+	if len(flags[0].aliases) != 1 || flags[0].aliases[0] != "q" {
+		t.Errorf("Option `queue` should have the alias `q` but got: %v", flags[0].aliases)
+	}
+
+	flags = toFlags("{--Q|k|queue}")
+	if len(flags) < 1 {
+		t.Errorf("Expected 1 value flag but got `%d`!", len(flags))
+		return
+	}
+	if !flags[0].hasName("Q") || !flags[0].hasName("k") || !flags[0].hasName("queue") || flags[0].hasName("q") {
+		t.Errorf("Option `queue` should be found by its aliases but got: %v", flags[0].aliases)
+	}
+}
+
+func TestCounterOption(t *testing.T) {
+	flags := toFlags("{-v|verbose+ : Verbosity} {--retry=+}")
+	if len(flags) != 2 {
+		t.Errorf("Expected 2 flags but got `%d`!", len(flags))
+		return
+	}
+	if flags[0].name != "verbose" || !flags[0].isCounter() || flags[0].acceptValue() || flags[0].description != "Verbosity" {
+		t.Errorf("Option `verbose` should be a counter without value but got: name=%s, options=%d", flags[0].name, flags[0].options)
+	}
+	if flags[1].isCounter() || !flags[1].isArray() || !flags[1].isRequired() {
+		t.Errorf("Option `retry` should have required array value but got: options=%d", flags[1].options)
+	}
+}
+
+func TestNegatableOption(t *testing.T) {
+	flags := toFlags("{-c|color! : Colorize the output}")
+	if len(flags) < 1 {
+		t.Errorf("Expected 1 value flag but got `%d`!", len(flags))
+		return
+	}
+	if flags[0].name != "color" || !flags[0].isNegatable() || flags[0].acceptValue() || flags[0].description != "Colorize the output" {
+		t.Errorf("Option `color` should be negatable without value but got: name=%s, options=%d", flags[0].name, flags[0].options)
+	}
+	if !reflect.DeepEqual(flags[0].negatedNames(), []string{"no-color"}) {
+		t.Errorf("Option `color` should only be negated by `no-color` but got: %v", flags[0].negatedNames())
+	}
+	if flags.option("no-color") != flags[0] || flags.option("no-c") != nil {
+		t.Errorf("Option `color` should be found by its negated name")
+	}
+}
+
+func TestMapOption(t *testing.T) {
+	flags := toFlags("{-l|label:map : Labels} {--env:map=tier=web}")
+	if len(flags) != 2 {
+		t.Errorf("Expected 2 flags but got `%d`!", len(flags))
+		return
+	}
+	if flags[0].name != "label" || flags[0].valueType != "map" || !flags[0].isRequired() || !flags[0].isArray() {
+		t.Errorf("Option `label` should have required array values but got: name=%s, options=%d", flags[0].name, flags[0].options)
+	}
+	if flags[1].value != "tier=web" || !flags[1].isArray() {
+		t.Errorf("Option `env` should have the default pair `tier=web` but got: val=%s, options=%d", flags[1].value, flags[1].options)
+	}
+
+	if _, err := ParseSignature("{--env:map=tier}"); err == nil {
+		t.Errorf("Expected an error for a default value that is not a key=value pair!")
+	}
+}
+
+func TestTypedFlags(t *testing.T) {
+	flags := toFlags("{port:int} {ratio:float?} {--timeout:duration=5s} {-H|host:ip=127.0.0.1 : The host}")
+	expected := []struct {
+		name      string
+		valueType string
+	}{
+		{"port", "int"},
+		{"ratio", "float"},
+		{"timeout", "duration"},
+		{"host", "ip"},
+	}
+
+	if len(flags) != len(expected) {
+		t.Errorf("Expected `%d` flags but got `%d`!", len(expected), len(flags))
+		return
+	}
+
+	for i, e := range expected {
+		if flags[i].name != e.name || flags[i].valueType != e.valueType {
+			t.Errorf("Expected Name: `%s`, Type: `%s`, but got: Name: `%s`, Type: `%s`", e.name, e.valueType, flags[i].name, flags[i].valueType)
+		}
+	}
+
+	if !flags[1].isOptional() || flags[2].value != "5s" || flags[3].description != "The host" || !flags[3].hasName("H") {
+		t.Errorf("Typed flags should keep their modifiers, defaults, descriptions and aliases!")
+	}
+
+	flags = toFlags("{--port:int} {-n:int=*}")
+	if !flags[0].acceptValue() || !flags[0].isRequired() || flags[0].isArray() {
+		t.Errorf("A typed option without `=` should require a value but got options `%d`!", flags[0].options)
+	}
+	if !flags[1].isOptional() || !flags[1].isArray() {
+		t.Errorf("A typed option should keep its value modifier but got options `%d`!", flags[1].options)
+	}
+}
+
+func TestTypedFlagsErrors(t *testing.T) {
+	for _, signature := range []string{"{port:integer}", "{--port:int=eighty}", "{--timeout:duration=5}", "{--level:int=low|high}"} {
+		if _, err := ParseSignature(signature); err == nil {
+			t.Errorf("Signature `%s` should be rejected!", signature)
+		}
+	}
+}
+
+func TestFlagsWithChoices(t *testing.T) {
+	flags := toFlags("{driver:mysql|pgsql} {--format=json|yaml|table : Output format} {--level:int=1|2|3} {--driver:mysql|pgsql}")
+
+	if len(flags) != 4 {
+		t.Errorf("Expected `%d` flags but got `%d`!", 4, len(flags))
+		return
+	}
+
+	if flags[0].name != "driver" || !flags[0].isRequired() || flags[0].value != "" || !reflect.DeepEqual(flags[0].choices, []string{"mysql", "pgsql"}) {
+		t.Errorf("Argument `driver` should be required with choices [mysql pgsql] but got: %v", flags[0].choices)
+	}
+
+	if flags[1].name != "format" || flags[1].value != "json" || flags[1].description != "Output format" || !reflect.DeepEqual(flags[1].choices, []string{"json", "yaml", "table"}) {
+		t.Errorf("Option `format` should default to json with choices [json yaml table] but got: %s, %v", flags[1].value, flags[1].choices)
+	}
+
+	if flags[2].valueType != "int" || flags[2].value != "1" || len(flags[2].choices) != 3 {
+		t.Errorf("Option `level` should be an int with choices [1 2 3] but got: %s, %v", flags[2].valueType, flags[2].choices)
+	}
+
+	if !flags[3].isRequired() || flags[3].value != "" || !reflect.DeepEqual(flags[3].choices, []string{"mysql", "pgsql"}) {
+		t.Errorf("Option `driver` should require one of [mysql pgsql] but got: options=%d, %v", flags[3].options, flags[3].choices)
+	}
+}
+
+func TestFlagsWithEnv(t *testing.T) {
+	flags := toFlags("{file @INPUT_FILE} {--queue=redis @QUEUE_NAME : The queue driver} {-f|force @FORCE}")
+
+	if len(flags) != 3 {
+		t.Errorf("Expected `%d` flags but got `%d`!", 3, len(flags))
+		return
+	}
+
+	if flags[0].name != "file" || flags[0].Env != "INPUT_FILE" {
+		t.Errorf("Argument `file` should be bound to INPUT_FILE but got: %s, %s", flags[0].name, flags[0].Env)
+	}
+
+	if flags[1].name != "queue" || flags[1].value != "redis" || flags[1].Env != "QUEUE_NAME" || flags[1].description != "The queue driver" {
+		t.Errorf("Option `queue` should be bound to QUEUE_NAME but got: %s, %s", flags[1].name, flags[1].Env)
+	}
+
+	if flags[2].name != "force" || flags[2].Env != "FORCE" {
+		t.Errorf("Option `force` should be bound to FORCE but got: %s, %s", flags[2].name, flags[2].Env)
+	}
+}
+
+func TestParseSignatureErrors(t *testing.T) {
+	tests := []struct {
+		signature string
+		pos       int
+		msg       string
+	}{
+		{"{file} {}", 7, "Flag cannot be empty! Syntax like {} is not acceptable"},
+		{"{files*} {other}", 9, "After an array argument, command cannot have any other arguments"},
+		{"{file} {--output=", 7, "The `{` is not closed"},
+		{"{file {--output=}", 0, "The `{` is not closed"},
+		{"{file} --output=}", 7, "Unexpected `-` outside of braces"},
+		{"{file}}", 6, "Unexpected `}` without a matching `{`"},
+		{"build {file}", 0, "Unexpected `b` outside of braces"},
+		{"{file} {-f|force} {--force=}", 18, "The `force` name is already used by the `force` flag"},
+		{"{-q|queue} {-q}", 11, "The `q` name is already used by the `queue` flag"},
+		{"{file} {file?}", 7, "The `file` name is already used by the `file` flag"},
+		{"{--|q}", 0, "Flag `--|q` has an empty name"},
+		{"{file} { --opt}", 7, "Flag ` --opt` has an invalid name ` --opt`"},
+		{"{file?:int}", 0, "Flag `file?:int` has an invalid name `file?`"},
+		{"{--dry run}", 0, "Flag `--dry run` has an invalid name `dry run`"},
+		{"{--fo!o}", 0, "Flag `--fo!o` has an invalid name `fo!o`"},
+		{"{-q|q}", 0, "The `q` name is used more than once by the `q` flag"},
+		{"{file} {--a|b|a}", 7, "The `a` name is used more than once by the `a` flag"},
+		{"{--no-color} {--color!}", 13, "The `no-color` name is already used by the `no-color` flag"},
+		{"{--color!} {--no-color=}", 11, "The `no-color` name is already used by the `color` flag"},
+		{"{port:number}", 0, "Unknown type `number` for `port`"},
+		{"{file} {--x:int+}", 7, "The `x` option takes no value, so it cannot have a type or choices"},
+		{"{-c|color:bool!}", 0, "The `color` option takes no value, so it cannot have a type or choices"},
+		{"{--format:json|yaml!}", 0, "The `format` option takes no value, so it cannot have a type or choices"},
+		{"{file} {--sep=|}", 7, "The choices `|` cannot be empty"},
+		{"{--format=json||yaml}", 0, "The choices `json||yaml` cannot be empty"},
+		{"{driver:mysql|}", 0, "The choices `mysql|` cannot be empty"},
+	}
+
+	for i, test := range tests {
+		flags, err := ParseSignature(test.signature)
+		signatureErr, ok := err.(*SignatureError)
+
+		if !ok || flags != nil {
+			t.Errorf("Test #%d expected a signature error for `%s` but got `%v`!", i+1, test.signature, err)
+			continue
+		}
+		if signatureErr.Pos != test.pos || signatureErr.Msg != test.msg {
+			t.Errorf("Test #%d expected error `%s` at %d but got `%s` at %d!", i+1, test.msg, test.pos, signatureErr.Msg, signatureErr.Pos)
+		}
+	}
+
+	// Arguments and options can share a name
+	if flags, err := ParseSignature(" {queue}\n {--queue=} "); err != nil || len(flags) != 2 {
+		t.Errorf("Expected a valid signature but got `%v`!", err)
+	}
+}
+
 func TestExtractDescriptionFunction(t *testing.T) {
 	name, description := extractDescription("ion : Hello world!")
 
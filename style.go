@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Colors and options of a style, i.e Style{Foreground: "white", Background: "red", Options: []string{"bold"}}.
+// The colors are black, red, green, yellow, blue, magenta, cyan, white, default and their
+// bright- variants, i.e bright-red. The options are bold, underscore, blink, reverse and conceal
+type Style struct {
+	Foreground string
+	Background string
+	Options    []string
+}
+
+var colorCodes = map[string]int{
+	"black":   30,
+	"red":     31,
+	"green":   32,
+	"yellow":  33,
+	"blue":    34,
+	"magenta": 35,
+	"cyan":    36,
+	"white":   37,
+	"default": 39,
+}
+
+var optionCodes = map[string]int{
+	"bold":       1,
+	"underscore": 4,
+	"blink":      5,
+	"reverse":    7,
+	"conceal":    8,
+}
+
+// The styles of the <info>, <comment>, <question>, <error> and <warning> tags
+func defaultStyles() map[string]Style {
+	return map[string]Style{
+		"info":     {Foreground: "green"},
+		"comment":  {Foreground: "yellow"},
+		"question": {Foreground: "black", Background: "cyan"},
+		"error":    {Foreground: "white", Background: "red"},
+		"warning":  {Foreground: "yellow"},
+	}
+}
+
+// Get the SGR code of a color, the background codes are 10 more than the foreground ones
+func colorCode(color string, background bool) (int, bool) {
+	bright := strings.HasPrefix(color, "bright-")
+	code, ok := colorCodes[strings.TrimPrefix(color, "bright-")]
+	if !ok || (bright && color == "bright-default") {
+		return 0, false
+	}
+
+	if bright {
+		code += 60
+	}
+	if background {
+		code += 10
+	}
+	return code, true
+}
+
+// Get the ANSI sequence that turns the style on, i.e `\x1b[37;41;1m`.
+// It's false when the style has an unknown color or option
+func (s Style) sequence() (string, bool) {
+	codes := []string{}
+
+	for i, color := range []string{s.Foreground, s.Background} {
+		if color == "" {
+			continue
+		}
+		code, ok := colorCode(strings.ToLower(color), i == 1)
+		if !ok {
+			return "", false
+		}
+		codes = append(codes, fmt.Sprint(code))
+	}
+
+	for _, option := range s.Options {
+		code, ok := optionCodes[strings.ToLower(option)]
+		if !ok {
+			return "", false
+		}
+		codes = append(codes, fmt.Sprint(code))
+	}
+
+	if len(codes) == 0 {
+		return "", true
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m", true
+}
+
+// Parse an inline style, i.e `fg=white;bg=red;options=bold,underscore`
+func parseStyle(definition string) (Style, bool) {
+	style := Style{}
+
+	for _, part := range strings.Split(definition, ";") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			return style, false
+		}
+
+		switch strings.ToLower(pair[0]) {
+		case "fg":
+			style.Foreground = pair[1]
+		case "bg":
+			style.Background = pair[1]
+		case "options":
+			style.Options = strings.Split(pair[1], ",")
+		default:
+			return style, false
+		}
+	}
+
+	_, ok := style.sequence()
+	return style, ok
+}
+
+// Merge the styles of the nested tags, the inner colors win and the options add up
+func mergeStyles(styles []Style) Style {
+	merged := Style{}
+
+	for _, style := range styles {
+		if style.Foreground != "" {
+			merged.Foreground = style.Foreground
+		}
+		if style.Background != "" {
+			merged.Background = style.Background
+		}
+		merged.Options = append(merged.Options, style.Options...)
+	}
+
+	return merged
+}
+
+// Replace the style tags, i.e `<info>Done!</info>` or `<fg=red;options=bold>Failed</>`, with
+// the ANSI sequences, or remove them when the output is not decorated. Tags that are not styles
+// are kept as they are and `\<` is a literal `<`
+func formatText(text string, styles map[string]Style, decorated bool) string {
+	output := strings.Builder{}
+	segment := strings.Builder{}
+	stack := []Style{}
+	names := []string{}
+
+	flush := func() {
+		if segment.Len() == 0 {
+			return
+		}
+		sequence, _ := mergeStyles(stack).sequence()
+		if decorated && sequence != "" {
+			output.WriteString(sequence + segment.String() + "\x1b[0m")
+		} else {
+			output.WriteString(segment.String())
+		}
+		segment.Reset()
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) && text[i+1] == '<' {
+			segment.WriteByte('<')
+			i++
+			continue
+		}
+
+		end := strings.IndexAny(text[i+1:], "<>")
+		if text[i] != '<' || end == -1 || text[i+1+end] != '>' {
+			segment.WriteByte(text[i])
+			continue
+		}
+		tag := text[i+1 : i+1+end]
+
+		if strings.HasPrefix(tag, "/") {
+			// Closing tags have to match the last opened tag, `</>` closes any
+			name := strings.ToLower(tag[1:])
+			if len(stack) == 0 || (name != "" && name != names[len(names)-1]) {
+				segment.WriteByte(text[i])
+				continue
+			}
+			flush()
+			stack, names = stack[:len(stack)-1], names[:len(names)-1]
+		} else {
+			name := strings.ToLower(tag)
+			style, ok := styles[name]
+			if !ok {
+				style, ok = parseStyle(tag)
+			}
+			if !ok || tag == "" {
+				segment.WriteByte(text[i])
+				continue
+			}
+			flush()
+			stack, names = append(stack, style), append(names, name)
+		}
+
+		i += end + 1
+	}
+	flush()
+
+	return output.String()
+}
+
+// Escape the `<` of the text, so it's never taken for a style tag, i.e for user input
+func EscapeTags(text string) string {
+	return strings.Replace(text, "<", "\\<", -1)
+}
+
+// Check if the output should be colored: --ansi and --no-ansi win, then the NO_COLOR
+// variable turns the colors off, otherwise they are used when the writer is a terminal
+func colorsEnabled(writer io.Writer, ansi Tristate) bool {
+	switch ansi {
+	case TristateTrue:
+		return true
+	case TristateFalse:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	file, ok := writer.(*os.File)
+	return ok && isTerminal(file.Fd())
+}
+
+// Replace the style tags of the text with the ANSI sequences, or remove them when the output is not colored
+func (ctx *Context) Format(text string) string {
+	return formatText(text, ctx.styles, ctx.decorated)
+}
+
+// Write a message with style tags, i.e `<info>Done!</info>`, followed by a new line.
+// Nothing is written in quiet mode
+func (ctx *Context) Line(msg string) {
+	if ctx.IsQuiet() {
+		return
+	}
+	fmt.Fprintln(ctx.Writer, ctx.Format(msg))
+}
+
+// Write a message with the info style
+func (ctx *Context) Info(msg string) {
+	ctx.Line("<info>" + msg + "</info>")
+}
+
+// Write a message with the comment style
+func (ctx *Context) Comment(msg string) {
+	ctx.Line("<comment>" + msg + "</comment>")
+}
+
+// Write a message with the warning style
+func (ctx *Context) Warn(msg string) {
+	ctx.Line("<warning>" + msg + "</warning>")
+}
+
+// Write a message with the error style. It's written even in quiet mode
+func (ctx *Context) Error(msg string) {
+	fmt.Fprintln(ctx.Writer, ctx.Format("<error>"+msg+"</error>"))
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFormatText(t *testing.T) {
+	styles := defaultStyles()
+	styles["success"] = Style{Foreground: "bright-green", Options: []string{"bold"}}
+
+	tests := []struct {
+		text      string
+		decorated string
+		plain     string
+	}{
+		{"no tags", "no tags", "no tags"},
+		{"<info>Done!</info>", "\x1b[32mDone!\x1b[0m", "Done!"},
+		{"<error>Failed</error> twice", "\x1b[37;41mFailed\x1b[0m twice", "Failed twice"},
+		{"<fg=red;bg=blue;options=bold,underscore>x</>", "\x1b[31;44;1;4mx\x1b[0m", "x"},
+		{"<success>ok</success>", "\x1b[92;1mok\x1b[0m", "ok"},
+		{"<info>a <fg=red>b</> c</info>", "\x1b[32ma \x1b[0m\x1b[31mb\x1b[0m\x1b[32m c\x1b[0m", "a b c"},
+		{"<comment>a <options=bold>b</></>", "\x1b[33ma \x1b[0m\x1b[33;1mb\x1b[0m", "a b"},
+		{"\\<info>escaped\\</info>", "<info>escaped</info>", "<info>escaped</info>"},
+		{"<div>html</div>", "<div>html</div>", "<div>html</div>"},
+		{"<info>a</comment></info>", "\x1b[32ma</comment>\x1b[0m", "a</comment>"},
+		{"<fg=purple>x</>", "<fg=purple>x</>", "<fg=purple>x</>"},
+		{"1 < 2 > 0 <>", "1 < 2 > 0 <>", "1 < 2 > 0 <>"},
+		{"</info> <info>unclosed", "</info> \x1b[32munclosed\x1b[0m", "</info> unclosed"},
+	}
+
+	for i, test := range tests {
+		if text := formatText(test.text, styles, true); text != test.decorated {
+			t.Errorf("Test #%d expected decorated %q but got %q!", i+1, test.decorated, text)
+		}
+		if text := formatText(test.text, styles, false); text != test.plain {
+			t.Errorf("Test #%d expected plain %q but got %q!", i+1, test.plain, text)
+		}
+	}
+
+	if text := EscapeTags("<b>user</b>"); formatText("<info>"+text+"</info>", styles, false) != "<b>user</b>" {
+		t.Errorf("Expected the escaped tags to be kept but got %q!", text)
+	}
+}
+
+func TestStyledOutput(t *testing.T) {
+	app, out := testApp(&Command{
+		Name: "deploy",
+		Action: func(ctx *Context) {
+			ctx.Line("<question>Deploying</question>")
+			ctx.Info("done")
+			ctx.Comment("comment")
+			ctx.Warn("careful")
+			ctx.Error("failed")
+		},
+	})
+	app.Styles["question"] = Style{Foreground: "blue"}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args("app", "deploy"), "Deploying\ndone\ncomment\ncareful\nfailed\n"},
+		{args("app", "deploy", "--no-ansi"), "Deploying\ndone\ncomment\ncareful\nfailed\n"},
+		{args("app", "deploy", "--ansi"), "\x1b[34mDeploying\x1b[0m\n\x1b[32mdone\x1b[0m\n\x1b[33mcomment\x1b[0m\n\x1b[33mcareful\x1b[0m\n\x1b[37;41mfailed\x1b[0m\n"},
+		{args("app", "deploy", "-q"), "failed\n"},
+	}
+
+	for i, test := range tests {
+		out.Reset()
+		if err := app.Run(test.args); err != nil {
+			t.Errorf("Test #%d failed with error: %s", i+1, err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("Test #%d expected output %q but got %q!", i+1, test.expected, out.String())
+		}
+	}
+}
+
+func TestAnsiOptionGivesWay(t *testing.T) {
+	app, out := testApp(&Command{
+		Name:      "build",
+		Signature: "{--ansi : Build for an ANSI terminal}",
+		Action: func(ctx *Context) {
+			ctx.Info("done")
+		},
+	})
+
+	if err := app.Run(args("app", "build", "--ansi")); err != nil {
+		t.Errorf("Run failed with error: %s", err)
+	}
+	if out.String() != "done\n" {
+		t.Errorf("The option of the command should not force the colors but got %q!", out.String())
+	}
+}
+
+func TestColorsEnabled(t *testing.T) {
+	noColor, ok := os.LookupEnv("NO_COLOR")
+	defer func() {
+		if ok {
+			os.Setenv("NO_COLOR", noColor)
+		} else {
+			os.Unsetenv("NO_COLOR")
+		}
+	}()
+
+	os.Setenv("NO_COLOR", "1")
+	if colorsEnabled(os.Stdout, TristateUnset) {
+		t.Errorf("NO_COLOR should turn the colors off!")
+	}
+	if !colorsEnabled(os.Stdout, TristateTrue) {
+		t.Errorf("--ansi should force the colors even with NO_COLOR!")
+	}
+
+	os.Unsetenv("NO_COLOR")
+	if colorsEnabled(&strings.Builder{}, TristateUnset) {
+		t.Errorf("The colors should be off for writers that are not terminals!")
+	}
+	if colorsEnabled(os.Stdout, TristateFalse) {
+		t.Errorf("--no-ansi should turn the colors off!")
+	}
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Compute the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			current := row[j]
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			row[j] = row[j] + 1
+			if row[j-1]+1 < row[j] {
+				row[j] = row[j-1] + 1
+			}
+			if prev+cost < row[j] {
+				row[j] = prev + cost
+			}
+			prev = current
+		}
+	}
+
+	return row[len(t)]
+}
+
+// Find the candidates that are at most `threshold` edits away from the name,
+// closest first. A candidate that would need every character replaced is not
+// a suggestion, so single letters don't suggest each other
+func suggest(name string, candidates []string, threshold int) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+
+	matches := []match{}
+	seen := map[string]bool{}
+	name = strings.ToLower(name)
+
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		distance := editDistance(name, strings.ToLower(candidate))
+		if distance > 0 && distance <= threshold && distance < len([]rune(name)) {
+			matches = append(matches, match{candidate, distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+
+	suggestions := []string{}
+	for _, m := range matches {
+		suggestions = append(suggestions, m.candidate)
+	}
+	return suggestions
+}
+
+// Build the hint added to the error messages, i.e " Did you mean `migrate`?"
+func suggestionHint(suggestions []string) string {
+	switch len(suggestions) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf(" Did you mean `%s`?", suggestions[0])
+	}
+	return fmt.Sprintf(" Did you mean one of these: `%s`?", strings.Join(suggestions, "`, `"))
+}
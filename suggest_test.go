@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"migrate", "migrate", 0},
+		{"migrat", "migrate", 1},
+		{"mgirate", "migrate", 2},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"db:seed", "db:sed", 1},
+	}
+
+	for _, test := range tests {
+		if distance := editDistance(test.a, test.b); distance != test.distance {
+			t.Errorf("Expected distance `%d` between `%s` and `%s` but got `%d`!", test.distance, test.a, test.b, distance)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"migrate", "make", "db:migrate", "db:seed", "v", "q"}
+
+	tests := []struct {
+		name        string
+		threshold   int
+		suggestions []string
+	}{
+		{"migrat", 2, []string{"migrate"}},
+		{"MIGRTE", 2, []string{"migrate"}},
+		{"db:migrat", 2, []string{"db:migrate"}},
+		{"db:sed", 2, []string{"db:seed"}},
+		{"mak", 2, []string{"make"}},
+		{"x", 2, []string{}},
+		{"migrat", 0, []string{}},
+		{"deploy", 2, []string{}},
+	}
+
+	for _, test := range tests {
+		if suggestions := suggest(test.name, candidates, test.threshold); !reflect.DeepEqual(suggestions, test.suggestions) {
+			t.Errorf("Expected suggestions %v for `%s` but got %v!", test.suggestions, test.name, suggestions)
+		}
+	}
+}
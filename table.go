@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Borders of a table, see Table.SetStyle
+type TableStyle int
+
+const (
+	// ┌──────┬─────┐ borders
+	TableUnicode TableStyle = iota
+	// +------+-----+ borders
+	TableASCII
+	// | Name | Age | pipes with the alignment row of the markdown tables
+	TableMarkdown
+	// Columns separated by spaces, without borders
+	TableCompact
+)
+
+// Alignment of a column, see Table.SetAlign
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// The characters of the bordered styles, from the top left corner to the bottom right one:
+// horizontal line, vertical line, then the left, middle and right parts of the top,
+// separator and bottom lines
+type tableBorders struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	middleLeft, middle, middleRight       string
+	bottomLeft, bottomMiddle, bottomRight string
+}
+
+var borders = map[TableStyle]tableBorders{
+	TableUnicode: {"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "└", "┴", "┘"},
+	TableASCII:   {"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+"},
+}
+
+// Table rendered into the writer of the context, i.e:
+//
+//	ctx.Table([]string{"Name", "Age"}, [][]string{{"Bob", "42"}}).SetAlign(1, AlignRight).Render()
+type Table struct {
+	headers []string
+	rows    [][]string
+	footer  []string
+
+	writer     io.Writer
+	style      TableStyle
+	aligns     map[int]Align
+	maxWidths  map[int]int
+	width      int
+	separators bool
+}
+
+// Creates a new table that writes into the context writer. The cells are wrapped
+// to fit the width of the terminal, if the writer is one, or the COLUMNS variable
+func (ctx *Context) Table(headers []string, rows [][]string) *Table {
+	return &Table{
+		headers:   headers,
+		rows:      rows,
+		writer:    ctx.Writer,
+		aligns:    map[int]Align{},
+		maxWidths: map[int]int{},
+		width:     writerWidth(ctx.Writer),
+	}
+}
+
+// Get the width of the terminal of the writer, then the one from the COLUMNS variable.
+// It's 0 when none is known
+func writerWidth(writer io.Writer) int {
+	if file, ok := writer.(*os.File); ok {
+		if width := terminalWidth(file.Fd()); width > 0 {
+			return width
+		}
+	}
+	width, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return width
+}
+
+// Set the borders of the table
+func (t *Table) SetStyle(style TableStyle) *Table {
+	t.style = style
+	return t
+}
+
+// Set the alignment of a column, the first one is 0
+func (t *Table) SetAlign(column int, align Align) *Table {
+	t.aligns[column] = align
+	return t
+}
+
+// Set the maximum width of a column, the longer cells are wrapped
+func (t *Table) SetMaxWidth(column int, width int) *Table {
+	t.maxWidths[column] = width
+	return t
+}
+
+// Set the width the table has to fit in, 0 for no limit
+func (t *Table) SetWidth(width int) *Table {
+	t.width = width
+	return t
+}
+
+// Set the cells displayed after the rows, i.e the totals
+func (t *Table) SetFooter(footer []string) *Table {
+	t.footer = footer
+	return t
+}
+
+// Draw a line between the rows
+func (t *Table) SetRowSeparators(separators bool) *Table {
+	t.separators = separators
+	return t
+}
+
+// Write the table into the writer
+func (t *Table) Render() {
+	var lines []string
+	if widths := t.columnWidths(); t.style == TableMarkdown {
+		lines = t.renderMarkdown(widths)
+	} else {
+		lines = t.renderBordered(widths)
+	}
+
+	for _, line := range lines {
+		io.WriteString(t.writer, strings.TrimRight(line, " ")+"\n")
+	}
+}
+
+// Write the rows between the borders. The compact style has no borders
+func (t *Table) renderBordered(widths []int) []string {
+	b := borders[t.style]
+	compact := t.style == TableCompact
+	lines := []string{}
+
+	line := func(left, middle, right string) {
+		if compact {
+			return
+		}
+		parts := []string{}
+		for _, width := range widths {
+			parts = append(parts, strings.Repeat(b.horizontal, width+2))
+		}
+		lines = append(lines, left+strings.Join(parts, middle)+right)
+	}
+	row := func(cells []string) {
+		for _, cells := range t.wrapRow(cells, widths) {
+			if compact {
+				lines = append(lines, strings.Join(cells, "  "))
+			} else {
+				lines = append(lines, b.vertical+" "+strings.Join(cells, " "+b.vertical+" ")+" "+b.vertical)
+			}
+		}
+	}
+
+	line(b.topLeft, b.topMiddle, b.topRight)
+	if t.headers != nil {
+		row(t.headers)
+		line(b.middleLeft, b.middle, b.middleRight)
+	}
+	for i, cells := range t.rows {
+		if i > 0 && t.separators {
+			line(b.middleLeft, b.middle, b.middleRight)
+		}
+		row(cells)
+	}
+	if t.footer != nil {
+		line(b.middleLeft, b.middle, b.middleRight)
+		row(t.footer)
+	}
+	line(b.bottomLeft, b.bottomMiddle, b.bottomRight)
+
+	return lines
+}
+
+// Write the rows as a markdown table. The cells are not wrapped, the new lines
+// are replaced with <br> and the footer is written as the last row
+func (t *Table) renderMarkdown(widths []int) []string {
+	lines := []string{}
+
+	row := func(cells []string) {
+		padded := []string{}
+		for i, width := range widths {
+			padded = append(padded, pad(markdownCell(cell(cells, i)), width, t.aligns[i]))
+		}
+		lines = append(lines, "| "+strings.Join(padded, " | ")+" |")
+	}
+
+	headers := t.headers
+	if headers == nil {
+		headers = make([]string, len(widths))
+	}
+	row(headers)
+
+	alignments := []string{}
+	for i, width := range widths {
+		switch t.aligns[i] {
+		case AlignCenter:
+			alignments = append(alignments, ":"+strings.Repeat("-", width-2)+":")
+		case AlignRight:
+			alignments = append(alignments, strings.Repeat("-", width-1)+":")
+		default:
+			alignments = append(alignments, strings.Repeat("-", width))
+		}
+	}
+	lines = append(lines, "| "+strings.Join(alignments, " | ")+" |")
+
+	for _, cells := range t.rows {
+		row(cells)
+	}
+	if t.footer != nil {
+		row(t.footer)
+	}
+
+	return lines
+}
+
+// Escape the pipes and the new lines of a markdown cell
+func markdownCell(text string) string {
+	text = strings.Replace(text, "|", "\\|", -1)
+	return strings.Replace(text, "\n", "<br>", -1)
+}
+
+// Wrap the cells of a row to the widths of the columns and pad them.
+// Returns the lines of the row, a cell can take more lines than the others
+func (t *Table) wrapRow(cells []string, widths []int) [][]string {
+	wrapped := [][]string{}
+	height := 1
+
+	for i, width := range widths {
+		lines := wrapText(cell(cells, i), width)
+		if len(lines) > height {
+			height = len(lines)
+		}
+		wrapped = append(wrapped, lines)
+	}
+
+	rows := [][]string{}
+	for line := 0; line < height; line++ {
+		row := []string{}
+		for i, width := range widths {
+			text := ""
+			if line < len(wrapped[i]) {
+				text = wrapped[i][line]
+			}
+			row = append(row, pad(text, width, t.aligns[i]))
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Find the width of every column. The columns are as wide as their widest cell, but not
+// wider than their maximum width, then the widest columns are shrunk until the table fits
+func (t *Table) columnWidths() []int {
+	widths := []int{}
+
+	for _, cells := range append(append([][]string{t.headers}, t.rows...), t.footer) {
+		for i, text := range cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if t.style == TableMarkdown {
+				text = markdownCell(text)
+			}
+			for _, line := range strings.Split(text, "\n") {
+				if width := displayWidth(line); width > widths[i] {
+					widths[i] = width
+				}
+			}
+		}
+	}
+
+	for i := range widths {
+		if limit := t.maxWidths[i]; limit > 0 && widths[i] > limit && t.style != TableMarkdown {
+			widths[i] = limit
+		}
+		// The alignment row of the markdown tables needs at least 3 dashes
+		if t.style == TableMarkdown && widths[i] < 3 {
+			widths[i] = 3
+		}
+	}
+
+	if t.width <= 0 || t.style == TableMarkdown {
+		return widths
+	}
+
+	for t.tableWidth(widths) > t.width {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 1 {
+			break
+		}
+		widths[widest]--
+	}
+
+	return widths
+}
+
+// Get the width of the table with the borders and the padding
+func (t *Table) tableWidth(widths []int) int {
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+
+	if t.style == TableCompact {
+		return total + 2*(len(widths)-1)
+	}
+	return total + 3*len(widths) + 1
+}
+
+// Get the cell of a row, the missing ones are empty
+func cell(cells []string, i int) string {
+	if i < len(cells) {
+		return cells[i]
+	}
+	return ""
+}
+
+// Pad the text to the width of the column
+func pad(text string, width int, align Align) string {
+	space := width - displayWidth(text)
+	if space <= 0 {
+		return text
+	}
+
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", space) + text
+	case AlignCenter:
+		return strings.Repeat(" ", space/2) + text + strings.Repeat(" ", space-space/2)
+	}
+	return text + strings.Repeat(" ", space)
+}
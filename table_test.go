@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	// The tables are wrapped to the width of the terminal
+	if columns, ok := os.LookupEnv("COLUMNS"); ok {
+		os.Unsetenv("COLUMNS")
+		defer os.Setenv("COLUMNS", columns)
+	}
+
+	headers := []string{"Name", "Age", "City"}
+	rows := [][]string{
+		{"Bob", "42", "Paris"},
+		{"Zoë", "7"},
+	}
+
+	tests := []struct {
+		table    func(*Context) *Table
+		expected string
+	}{
+		{
+			func(ctx *Context) *Table {
+				return ctx.Table(headers, rows).SetAlign(1, AlignRight)
+			},
+			`┌──────┬─────┬───────┐
+│ Name │ Age │ City  │
+├──────┼─────┼───────┤
+│ Bob  │  42 │ Paris │
+│ Zoë  │   7 │       │
+└──────┴─────┴───────┘
+`,
+		},
+		{
+			func(ctx *Context) *Table {
+				return ctx.Table(headers, rows).SetStyle(TableASCII).SetAlign(2, AlignCenter).SetRowSeparators(true).SetFooter([]string{"Total", "49"})
+			},
+			`+-------+-----+-------+
+| Name  | Age | City  |
++-------+-----+-------+
+| Bob   | 42  | Paris |
++-------+-----+-------+
+| Zoë   | 7   |       |
++-------+-----+-------+
+| Total | 49  |       |
++-------+-----+-------+
+`,
+		},
+		{
+			func(ctx *Context) *Table {
+				return ctx.Table(headers, [][]string{{"Bob|Alice", "42", "New\nYork"}}).SetStyle(TableMarkdown).SetAlign(1, AlignRight).SetAlign(2, AlignCenter)
+			},
+			`| Name       | Age |    City     |
+| ---------- | --: | :---------: |
+| Bob\|Alice |  42 | New<br>York |
+`,
+		},
+		{
+			func(ctx *Context) *Table {
+				return ctx.Table(headers, rows).SetStyle(TableCompact)
+			},
+			`Name  Age  City
+Bob   42   Paris
+Zoë   7
+`,
+		},
+		{
+			func(ctx *Context) *Table {
+				return ctx.Table(nil, [][]string{{"日本", "a long description"}}).SetMaxWidth(1, 8)
+			},
+			`┌──────┬──────────┐
+│ 日本 │ a long   │
+│      │ descript │
+│      │ ion      │
+└──────┴──────────┘
+`,
+		},
+		{
+			func(ctx *Context) *Table {
+				return ctx.Table([]string{"Key", "Value"}, [][]string{{"\x1b[32mok\x1b[0m", "the quick brown fox"}}).SetWidth(20)
+			},
+			"┌─────┬────────────┐\n│ Key │ Value      │\n├─────┼────────────┤\n│ \x1b[32mok\x1b[0m  │ the quick  │\n│     │ brown fox  │\n└─────┴────────────┘\n",
+		},
+	}
+
+	for i, test := range tests {
+		ctx, out := promptContext("")
+		test.table(ctx).Render()
+
+		if out.String() != test.expected {
+			t.Errorf("Test #%d expected table:\n%s\nbut got:\n%s", i+1, test.expected, out.String())
+		}
+	}
+}
+
+func TestTableWidthFromEnv(t *testing.T) {
+	columns, ok := os.LookupEnv("COLUMNS")
+	os.Setenv("COLUMNS", "12")
+	defer func() {
+		if ok {
+			os.Setenv("COLUMNS", columns)
+		} else {
+			os.Unsetenv("COLUMNS")
+		}
+	}()
+
+	ctx, out := promptContext("")
+	ctx.Table(nil, [][]string{{"the quick brown fox"}}).SetStyle(TableCompact).Render()
+
+	if expected := "the quick\nbrown fox\n"; out.String() != expected {
+		t.Errorf("Expected the table wrapped to the COLUMNS variable but got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+// A terminal that can hide what the user types, i.e the reader of a context that reads
+// from a tty. Readers that implement it are used as they are, so a pseudo-terminal can be faked
+type Terminal interface {
+	// Turn the echo off and return the func that restores the previous state
+	DisableEcho() (restore func() error, err error)
+}
+
+// The terminal of an *os.File, i.e os.Stdin
+type fileTerminal struct {
+	fd uintptr
+}
+
+func (t fileTerminal) DisableEcho() (func() error, error) {
+	return disableEcho(t.fd)
+}
+
+// Get the terminal of the context reader, nil when it's not one, i.e piped input
+func (ctx *Context) terminal() Terminal {
+	switch reader := ctx.Reader.(type) {
+	case Terminal:
+		return reader
+	case *os.File:
+		if isTerminal(reader.Fd()) {
+			return fileTerminal{reader.Fd()}
+		}
+	}
+	return nil
+}
+
+// Display a message then waits for an answer without showing what is typed, i.e for
+// passwords, and keeps the spaces around it. When the reader is not a terminal the answer
+// is read like any other one, after writing the SecretWarning, if there is one. The echo
+// is turned on again even when the reading fails, it's interrupted or it panics
+func (ctx *Context) Secret(msg string) (string, error) {
+	fmt.Fprint(ctx.Writer, msg)
+
+	terminal := ctx.terminal()
+	if terminal == nil {
+		if ctx.SecretWarning != "" {
+			fmt.Fprintln(ctx.Writer)
+			fmt.Fprintln(ctx.Writer, ctx.SecretWarning)
+		}
+		return ctx.readRawLine()
+	}
+
+	restore, err := terminal.DisableEcho()
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	answer, err := ctx.readRawLine()
+	// The new line typed by the user was not displayed either
+	fmt.Fprintln(ctx.Writer)
+
+	return answer, err
+}
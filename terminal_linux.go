@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Get the state of the terminal
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+	termios := &syscall.Termios{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return nil, errno
+	}
+	return termios, nil
+}
+
+// Change the state of the terminal
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Check if the file descriptor is a terminal
+func isTerminal(fd uintptr) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// Turn the echo off, but keep the lines and the signals like Ctrl+C working
+func disableEcho(fd uintptr) (func() error, error) {
+	state, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	noEcho := *state
+	noEcho.Lflag &^= syscall.ECHO
+	noEcho.Lflag |= syscall.ICANON | syscall.ISIG
+	noEcho.Iflag |= syscall.ICRNL
+	if err := setTermios(fd, &noEcho); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return setTermios(fd, state)
+	}, nil
+}
+
+// Get the number of columns of the terminal, 0 when it's not a terminal
+func terminalWidth(fd uintptr) int {
+	size := struct{ rows, cols, x, y uint16 }{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size))); errno != 0 {
+		return 0
+	}
+	return int(size.cols)
+}
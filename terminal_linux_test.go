@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// Open a pseudo-terminal and return its slave side
+func openPty(t *testing.T) (*os.File, *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("Pseudo-terminals are not available: %s", err)
+	}
+
+	unlock, n := 0, uint32(0)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		master.Close()
+		t.Skipf("The pseudo-terminal can't be unlocked: %s", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		master.Close()
+		t.Skipf("The pseudo-terminal has no number: %s", errno)
+	}
+
+	slave, err := os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR, 0)
+	if err != nil {
+		master.Close()
+		t.Skipf("The pseudo-terminal can't be opened: %s", err)
+	}
+	return master, slave
+}
+
+func TestDisableEcho(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	if !isTerminal(slave.Fd()) {
+		t.Fatalf("Expected the pseudo-terminal to be a terminal!")
+	}
+
+	tmp, err := ioutil.TempFile("", "cli")
+	if err == nil {
+		defer os.Remove(tmp.Name())
+		if isTerminal(tmp.Fd()) {
+			t.Errorf("Regular files should not be terminals!")
+		}
+	}
+
+	restore, err := disableEcho(slave.Fd())
+	if err != nil {
+		t.Fatalf("Disabling the echo failed with error: %s", err)
+	}
+	if state, _ := getTermios(slave.Fd()); state.Lflag&syscall.ECHO != 0 {
+		t.Errorf("Expected the echo to be disabled!")
+	}
+
+	if err := restore(); err != nil {
+		t.Fatalf("Restoring the terminal failed with error: %s", err)
+	}
+	if state, _ := getTermios(slave.Fd()); state.Lflag&syscall.ECHO == 0 {
+		t.Errorf("Expected the echo to be restored!")
+	}
+}
+
+func TestColorsOfTerminals(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+	defer slave.Close()
+
+	noColor, ok := os.LookupEnv("NO_COLOR")
+	os.Unsetenv("NO_COLOR")
+	if ok {
+		defer os.Setenv("NO_COLOR", noColor)
+	}
+
+	if !colorsEnabled(slave, TristateUnset) {
+		t.Errorf("The output should be colored when the writer is a terminal!")
+	}
+}
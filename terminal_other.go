@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package cli
+
+import "errors"
+
+// Only the Linux terminals are supported for now
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func disableEcho(fd uintptr) (func() error, error) {
+	return nil, errors.New("Hiding the input is not supported on this platform!")
+}
+
+func terminalWidth(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Pseudo-terminal that reads the given input and records the echo changes
+type fakeTerminal struct {
+	io.Reader
+	echo     bool
+	disabled int
+}
+
+func (t *fakeTerminal) DisableEcho() (func() error, error) {
+	t.echo = false
+	t.disabled++
+	return func() error {
+		t.echo = true
+		return nil
+	}, nil
+}
+
+// Writer that panics on the new line written after the secret
+type panicWriter struct {
+	bytes.Buffer
+}
+
+func (w *panicWriter) Write(p []byte) (int, error) {
+	if string(p) == "\n" {
+		panic("broken writer")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestSecret(t *testing.T) {
+	terminal := &fakeTerminal{Reader: strings.NewReader("s3cr3t\n  pass word  \r\nvisible\n"), echo: true}
+	ctx, out := promptContext("")
+	ctx.Reader = terminal
+	ctx.SecretWarning = "The token will be visible!"
+
+	secret, err := ctx.Secret("Token: ")
+	if err != nil || secret != "s3cr3t" {
+		t.Errorf("Expected secret `s3cr3t` but got `%s` (%v)!", secret, err)
+	}
+	if !terminal.echo || terminal.disabled != 1 {
+		t.Errorf("Expected the echo to be disabled once and restored!")
+	}
+	if out.String() != "Token: \n" {
+		t.Errorf("Expected the message and a new line but got `%s`!", out.String())
+	}
+
+	if secret, err := ctx.Secret("Token: "); err != nil || secret != "  pass word  " {
+		t.Errorf("Expected the spaces of the secret to be kept but got `%s` (%v)!", secret, err)
+	}
+
+	// The answers of the other prompts are not lost
+	if answer, err := ctx.Ask("Name: "); err != nil || answer != "visible" {
+		t.Errorf("Expected answer `visible` but got `%s` (%v)!", answer, err)
+	}
+
+	if _, err := ctx.Secret("Token: "); err != io.EOF || !terminal.echo {
+		t.Errorf("Expected EOF error with the echo restored but got `%v`!", err)
+	}
+}
+
+func TestSecretRestoresTheEchoOnPanic(t *testing.T) {
+	terminal := &fakeTerminal{Reader: strings.NewReader("s3cr3t\n"), echo: true}
+	ctx := newContext(terminal, &panicWriter{}, map[string]*Result{}, map[string]*Result{})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected the panic of the writer!")
+		}
+		if !terminal.echo {
+			t.Errorf("Expected the echo to be restored after a panic!")
+		}
+	}()
+	ctx.Secret("Token: ")
+}
+
+func TestSecretWithoutTerminal(t *testing.T) {
+	ctx, out := promptContext("s3cr3t\n")
+	ctx.SecretWarning = "The token will be visible!"
+
+	if secret, err := ctx.Secret("Token: "); err != nil || secret != "s3cr3t" {
+		t.Errorf("Expected secret `s3cr3t` but got `%s` (%v)!", secret, err)
+	}
+	if out.String() != "Token: \nThe token will be visible!\n" {
+		t.Errorf("Expected the warning but got `%s`!", out.String())
+	}
+
+	ctx, out = promptContext("s3cr3t\n")
+	ctx.Secret("Token: ")
+	if out.String() != "Token: " {
+		t.Errorf("Expected no warning by default but got `%s`!", out.String())
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type of the values accepted by a flag, i.e {port:int} or {--timeout:duration=5s}
+type valueType struct {
+	// What the type expects, used in the error messages
+	expects string
+	check   func(string) error
+	// Runtime types like files can't be checked when the signature is parsed
+	runtime bool
+}
+
+var valueTypes = map[string]valueType{
+	"int": {
+		expects: "an integer",
+		check: func(v string) error {
+			_, err := strconv.Atoi(v)
+			return err
+		},
+	},
+	"float": {
+		expects: "a number",
+		check: func(v string) error {
+			_, err := strconv.ParseFloat(v, 64)
+			return err
+		},
+	},
+	"bool": {
+		expects: "a boolean",
+		check: func(v string) error {
+			_, err := strconv.ParseBool(v)
+			return err
+		},
+	},
+	"duration": {
+		expects: "a duration",
+		check: func(v string) error {
+			_, err := time.ParseDuration(v)
+			return err
+		},
+	},
+	"url": {
+		expects: "a URL",
+		check: func(v string) error {
+			u, err := url.ParseRequestURI(v)
+			if err == nil && (u.Scheme == "" || u.Host == "") {
+				return errors.New("URL without scheme or host")
+			}
+			return err
+		},
+	},
+	"ip": {
+		expects: "an IP address",
+		check: func(v string) error {
+			if net.ParseIP(v) == nil {
+				return errors.New("invalid IP address")
+			}
+			return nil
+		},
+	},
+	"path": {
+		expects: "a path",
+		check: func(v string) error {
+			if v == "" || strings.ContainsRune(v, 0) {
+				return errors.New("invalid path")
+			}
+			return nil
+		},
+	},
+	"file": {
+		expects: "an existing file",
+		check: func(v string) error {
+			info, err := os.Stat(v)
+			if err == nil && info.IsDir() {
+				return errors.New("directory instead of file")
+			}
+			return err
+		},
+		runtime: true,
+	},
+	"map": {
+		expects: "a key=value pair",
+		check: func(v string) error {
+			_, _, err := splitPair(v)
+			return err
+		},
+	},
+}
+
+// Split a key=value pair of a map flag, i.e {--label:map}. The value can be empty
+func splitPair(pair string) (string, string, error) {
+	parts := strings.SplitN(pair, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("`%s` is not a key=value pair", pair)
+	}
+	return parts[0], parts[1], nil
+}
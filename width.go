@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ANSI escape sequences, i.e the colors `\x1b[32m` and `\x1b[0m`
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;?]*[ -/]*[@-~]")
+
+// The East Asian wide and fullwidth characters and the emojis take two columns
+var wideRanges = []struct{ from, to rune }{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// Remove the ANSI escape sequences from the text
+func stripAnsi(text string) string {
+	return ansiSequence.ReplaceAllString(text, "")
+}
+
+// Get the number of columns taken by the rune in a terminal
+func runeWidth(r rune) int {
+	if r < 0x20 || r == 0x7F || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r >= wide.from && r <= wide.to {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Get the number of columns taken by the text in a terminal, without the ANSI sequences
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range stripAnsi(text) {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// Wrap the text into lines of at most `width` columns. The lines are broken at the spaces
+// when possible and the ANSI styles are closed at the end of a line and opened again on the next one
+func wrapText(text string, width int) []string {
+	lines := []string{}
+
+	for _, line := range strings.Split(text, "\n") {
+		if width <= 0 || displayWidth(line) <= width {
+			lines = append(lines, line)
+			continue
+		}
+
+		current, currentWidth := "", 0
+		for _, word := range strings.Split(line, " ") {
+			wordWidth := displayWidth(word)
+			if currentWidth > 0 && currentWidth+1+wordWidth <= width {
+				current += " " + word
+				currentWidth += 1 + wordWidth
+				continue
+			}
+			if currentWidth > 0 || current != "" {
+				lines = append(lines, current)
+			}
+
+			current, currentWidth = word, wordWidth
+			if wordWidth > width {
+				chunks := splitWidth(word, width)
+				lines = append(lines, chunks[:len(chunks)-1]...)
+				current = chunks[len(chunks)-1]
+				currentWidth = displayWidth(current)
+			}
+		}
+		lines = append(lines, current)
+	}
+
+	return carryStyles(lines)
+}
+
+// Split a word into chunks of at most `width` columns, keeping the ANSI sequences
+func splitWidth(word string, width int) []string {
+	chunks := []string{}
+	chunk, chunkWidth := "", 0
+
+	for len(word) > 0 {
+		if loc := ansiSequence.FindStringIndex(word); loc != nil && loc[0] == 0 {
+			chunk += word[:loc[1]]
+			word = word[loc[1]:]
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(word)
+		if w := runeWidth(r); chunkWidth+w > width && chunkWidth > 0 {
+			chunks = append(chunks, chunk)
+			chunk, chunkWidth = "", 0
+		}
+		chunk += word[:size]
+		chunkWidth += runeWidth(r)
+		word = word[size:]
+	}
+
+	return append(chunks, chunk)
+}
+
+// Close the styles that are still active at the end of a line and open them again
+// on the next one, so the borders of a table are not colored
+func carryStyles(lines []string) []string {
+	active := ""
+
+	for i, line := range lines {
+		line = active + line
+		for _, sequence := range ansiSequence.FindAllString(lines[i], -1) {
+			switch {
+			case !strings.HasSuffix(sequence, "m"):
+				continue
+			case sequence == "\x1b[0m" || sequence == "\x1b[m":
+				active = ""
+			default:
+				active += sequence
+			}
+		}
+		if active != "" {
+			line += "\x1b[0m"
+		}
+		lines[i] = line
+	}
+
+	return lines
+}
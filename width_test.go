@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"\x1b[32mhello\x1b[0m", 5},
+		{"\x1b[1;37;41mhi\x1b[m", 2},
+		{"日本語", 6},
+		{"한국어 ok", 9},
+		{"ｆｕｌｌ", 8},
+		{"café", 4},
+		{"café", 4},
+		{"🚀 go", 5},
+	}
+
+	for i, test := range tests {
+		if width := displayWidth(test.text); width != test.expected {
+			t.Errorf("Test #%d expected width `%d` for %q but got `%d`!", i+1, test.expected, test.text, width)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text     string
+		width    int
+		expected []string
+	}{
+		{"short", 10, []string{"short"}},
+		{"the quick brown fox", 10, []string{"the quick", "brown fox"}},
+		{"a\nnew line", 0, []string{"a", "new line"}},
+		{"abcdefghij kl", 4, []string{"abcd", "efgh", "ij", "kl"}},
+		{"日本語のテキスト", 5, []string{"日本", "語の", "テキ", "スト"}},
+		{"\x1b[32mgreen text here\x1b[0m ok", 10, []string{"\x1b[32mgreen text\x1b[0m", "\x1b[32mhere\x1b[0m ok"}},
+	}
+
+	for i, test := range tests {
+		if lines := wrapText(test.text, test.width); !reflect.DeepEqual(lines, test.expected) {
+			t.Errorf("Test #%d expected lines %q but got %q!", i+1, test.expected, lines)
+		}
+	}
+}